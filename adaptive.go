@@ -0,0 +1,179 @@
+package main
+
+import (
+        "context"
+        "fmt"
+        "sync"
+        "time"
+)
+
+// Adaptive concurrency settings
+const (
+        adaptiveInterval   = 2 * time.Second // how often the limit is reconsidered
+        adaptiveWindow     = 200             // recent results the decision is based on
+        adaptiveMinSamples = 10              // fewer new results than this leave the limit alone
+        adaptiveBackoff    = 0.10            // failure rate that halves the limit
+        adaptiveHealthy    = 0.02            // failure rate below which the limit grows
+)
+
+// adaptiveSample is what the controller remembers about one result
+type adaptiveSample struct {
+        failed   bool
+        duration time.Duration
+}
+
+// concurrencyChange is one entry of the timeline shown in verbose mode
+type concurrencyChange struct {
+        at          time.Duration
+        from, to    int
+        failureRate float64
+        avgDuration time.Duration
+}
+
+// adaptiveLimiter admits workers through a token count that the controller
+// raises while the network is healthy and halves when failures or latency
+// spike. All -max-workers goroutines run, but only limit of them check at once.
+type adaptiveLimiter struct {
+        mu       sync.Mutex
+        cond     *sync.Cond
+        limit    int
+        active   int
+        min, max int
+
+        samples   [adaptiveWindow]adaptiveSample
+        recorded  int // total samples, also the next write position modulo the window
+        seen      int // recorded at the last adjustment
+        baseline  time.Duration
+        backedOff bool // growth is slower once the limit has been cut
+
+        start    time.Time
+        timeline []concurrencyChange
+}
+
+func newAdaptiveLimiter(minWorkers, maxWorkers, initial int) *adaptiveLimiter {
+        l := &adaptiveLimiter{
+                limit: max(minWorkers, min(initial, maxWorkers)),
+                min:   minWorkers,
+                max:   maxWorkers,
+                start: time.Now(),
+        }
+        l.cond = sync.NewCond(&l.mu)
+        return l
+}
+
+// acquire waits for a free token, returning false if ctx is cancelled first
+func (l *adaptiveLimiter) acquire(ctx context.Context) bool {
+        l.mu.Lock()
+        defer l.mu.Unlock()
+        for l.active >= l.limit && ctx.Err() == nil {
+                l.cond.Wait()
+        }
+        if ctx.Err() != nil {
+                return false
+        }
+        l.active++
+        return true
+}
+
+// release returns a token and records the result it was used for
+func (l *adaptiveLimiter) release(result Result) {
+        l.mu.Lock()
+        defer l.mu.Unlock()
+        l.active--
+        // DNS failures say more about the input than the network, so they
+        // don't count against the limit; neither do skipped targets
+        failed := result.Error != nil && result.Category != CategoryDNS
+        if !result.OutOfScope {
+                l.samples[l.recorded%adaptiveWindow] = adaptiveSample{failed: failed, duration: result.Duration}
+                l.recorded++
+        }
+        l.cond.Signal()
+}
+
+// run adjusts the limit every adaptiveInterval until done is closed,
+// waking any waiting workers when ctx is cancelled
+func (l *adaptiveLimiter) run(ctx context.Context, done <-chan struct{}) {
+        ticker := time.NewTicker(adaptiveInterval)
+        defer ticker.Stop()
+        for {
+                select {
+                case <-done:
+                        return
+                case <-ctx.Done():
+                        l.mu.Lock()
+                        l.cond.Broadcast()
+                        l.mu.Unlock()
+                        return
+                case <-ticker.C:
+                        l.adjust()
+                }
+        }
+}
+
+// adjust updates the limit from the results recorded since the last
+// adjustment: doubling until the first backoff, then growing by a quarter,
+// and halving whenever failures or latency spike
+func (l *adaptiveLimiter) adjust() {
+        l.mu.Lock()
+        defer l.mu.Unlock()
+
+        fresh := min(l.recorded-l.seen, adaptiveWindow)
+        if fresh < adaptiveMinSamples {
+                return
+        }
+        l.seen = l.recorded
+
+        failures := 0
+        var total time.Duration
+        for i := 1; i <= fresh; i++ {
+                sample := l.samples[(l.recorded-i)%adaptiveWindow]
+                if sample.failed {
+                        failures++
+                }
+                total += sample.duration
+        }
+        failureRate := float64(failures) / float64(fresh)
+        avg := total / time.Duration(fresh)
+
+        // The fastest healthy window is the latency the network can deliver
+        if failureRate < adaptiveBackoff && (l.baseline == 0 || avg < l.baseline) {
+                l.baseline = avg
+        }
+
+        limit := l.limit
+        switch {
+        case failureRate >= adaptiveBackoff || avg > 2*l.baseline:
+                limit = max(l.min, limit/2)
+                l.backedOff = true
+        case failureRate < adaptiveHealthy && !l.backedOff:
+                limit = min(l.max, 2*limit)
+        case failureRate < adaptiveHealthy:
+                limit = min(l.max, limit+max(1, limit/4))
+        }
+        if limit == l.limit {
+                return
+        }
+
+        l.timeline = append(l.timeline, concurrencyChange{
+                at:          time.Since(l.start),
+                from:        l.limit,
+                to:          limit,
+                failureRate: failureRate,
+                avgDuration: avg,
+        })
+        l.limit = limit
+        l.cond.Broadcast()
+}
+
+// printTimeline lists every change of the worker limit
+func (l *adaptiveLimiter) printTimeline() {
+        l.mu.Lock()
+        defer l.mu.Unlock()
+
+        fmt.Fprintf(infoOut, "\n%s----● Concurrency Timeline ●----%s\n", Magenta, Reset)
+        fmt.Fprintf(infoOut, "Bounds: %d-%d workers, final limit %d\n", l.min, l.max, l.limit)
+        for _, change := range l.timeline {
+                fmt.Fprintf(infoOut, "%6.1fs  %4d -> %-4d (failures %.1f%%, avg %.2fs)\n",
+                        change.at.Seconds(), change.from, change.to, change.failureRate*100, change.avgDuration.Seconds())
+        }
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+        "bufio"
+        "encoding/json"
+        "fmt"
+        "os"
+        "sort"
+        "strings"
+)
+
+// loadResults reads a JSONL file written by -output-form json, keyed by
+// compareKey. When a domain appears more than once the last record wins.
+func loadResults(path string) (map[string]jsonResult, error) {
+        file, err := os.Open(path)
+        if err != nil {
+                return nil, err
+        }
+        defer file.Close()
+
+        results := make(map[string]jsonResult)
+        scanner := bufio.NewScanner(file)
+        scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+        for lineNum := 1; scanner.Scan(); lineNum++ {
+                line := strings.TrimSpace(scanner.Text())
+                if line == "" {
+                        continue
+                }
+                var jr jsonResult
+                if err := json.Unmarshal([]byte(line), &jr); err != nil || jr.Domain == "" {
+                        fmt.Fprintf(os.Stderr, "%sSkipping line %d of %s: not a result record%s\n", Magenta, lineNum, path, Reset)
+                        continue
+                }
+                results[compareKey(jr.Domain)] = jr
+        }
+        return results, scanner.Err()
+}
+
+// runCompare prints the domains that appeared, disappeared or changed status
+// code between two JSONL result files. No requests are made.
+func runCompare(oldPath, newPath string) error {
+        oldResults, err := loadResults(oldPath)
+        if err != nil {
+                return err
+        }
+        newResults, err := loadResults(newPath)
+        if err != nil {
+                return err
+        }
+
+        added, removed, changed := diffResults(oldResults, newResults)
+
+        fmt.Printf("\n%s----● New Domains (%d) ●----%s\n", Magenta, len(added), Reset)
+        for _, key := range added {
+                jr := newResults[key]
+                fmt.Printf("%s+ %-50s %s%s\n", Green, jr.Domain, compareStatus(jr), Reset)
+        }
+
+        fmt.Printf("\n%s----● Gone Domains (%d) ●----%s\n", Magenta, len(removed), Reset)
+        for _, key := range removed {
+                jr := oldResults[key]
+                fmt.Printf("%s- %-50s %s%s\n", Red, jr.Domain, compareStatus(jr), Reset)
+        }
+
+        fmt.Printf("\n%s----● Changed Status (%d) ●----%s\n", Magenta, len(changed), Reset)
+        for _, key := range changed {
+                before, after := oldResults[key], newResults[key]
+                fmt.Printf("%s~ %-50s %s%s%s -> %s%s%s\n", Yellow, after.Domain,
+                        statusColor(before.StatusCode), compareStatus(before), Reset,
+                        statusColor(after.StatusCode), compareStatus(after), Reset)
+        }
+        return nil
+}
+
+// diffResults returns the sorted keys that only the new results have, that
+// only the old results have, and whose status code changed
+func diffResults(oldResults, newResults map[string]jsonResult) (added, removed, changed []string) {
+        for key, jr := range newResults {
+                previous, ok := oldResults[key]
+                switch {
+                case !ok:
+                        added = append(added, key)
+                case previous.StatusCode != jr.StatusCode:
+                        changed = append(changed, key)
+                }
+        }
+        for key := range oldResults {
+                if _, ok := newResults[key]; !ok {
+                        removed = append(removed, key)
+                }
+        }
+        sort.Strings(added)
+        sort.Strings(removed)
+        sort.Strings(changed)
+        return added, removed, changed
+}
+
+// compareKey identifies a domain across runs. Matched results are recorded
+// without the https:// that failed ones carry, so both forms share a key.
+func compareKey(domain string) string {
+        if !strings.Contains(domain, "://") {
+                domain = "https://" + domain
+        }
+        return strings.ToLower(domain)
+}
+
+// compareStatus formats a result's status code for the comparison listing
+func compareStatus(jr jsonResult) string {
+        if jr.Error != "" {
+                return "000 Failed"
+        }
+        return fmt.Sprintf("%03d", jr.StatusCode)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+        "reflect"
+        "testing"
+)
+
+func TestDiffResultsNormalizesDomains(t *testing.T) {
+        results := func(records ...jsonResult) map[string]jsonResult {
+                m := make(map[string]jsonResult)
+                for _, jr := range records {
+                        m[compareKey(jr.Domain)] = jr
+                }
+                return m
+        }
+        oldResults := results(
+                jsonResult{Domain: "https://a.com", Status: "Failed", Error: "refused"},
+                jsonResult{Domain: "b.com", StatusCode: 200},
+                jsonResult{Domain: "http://c.com:8080", StatusCode: 200},
+        )
+        newResults := results(
+                jsonResult{Domain: "a.com", StatusCode: 200},
+                jsonResult{Domain: "https://b.com", StatusCode: 404},
+                jsonResult{Domain: "d.com", StatusCode: 200},
+        )
+
+        added, removed, changed := diffResults(oldResults, newResults)
+        if want := []string{"https://d.com"}; !reflect.DeepEqual(added, want) {
+                t.Errorf("added = %q, want %q", added, want)
+        }
+        if want := []string{"http://c.com:8080"}; !reflect.DeepEqual(removed, want) {
+                t.Errorf("removed = %q, want %q", removed, want)
+        }
+        if want := []string{"https://a.com", "https://b.com"}; !reflect.DeepEqual(changed, want) {
+                t.Errorf("changed = %q, want %q", changed, want)
+        }
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+        "crypto/tls"
+        "flag"
+        "fmt"
+        "net"
+        "net/http"
+        "net/url"
+        "os"
+        "runtime"
+        "strings"
+        "text/template"
+        "time"
+)
+
+// Config holds the options set on the command line
+type Config struct {
+        Domains             stringList
+        Scope               stringList
+        Wordlist            string
+        WWW                 bool
+        Limit               int
+        Bases               stringList
+        Workers             int
+        MinWorkers          int
+        MaxWorkers          int
+        Timeout             time.Duration
+        Proxy               string
+        LocalAddrs          stringList
+        Method              string
+        Body                string
+        ContentType         string
+        Ramp                time.Duration
+        NoColor             bool
+        NoBanner            bool
+        Verbose             bool
+        Silent              bool
+        StdoutMatchesOnly   bool
+        Pipe                bool
+        OutputForm          string
+        StatsInterval       time.Duration
+        Stats               bool
+        SecondPass          bool
+        SecondPassWorkers   int
+        Serve               string
+        Webhook             string
+        Output              string
+        Append              bool
+        AbortFailRate       float64
+        MaxDuration         time.Duration
+        MaxIdleConns        int
+        MaxIdleConnsPerHost int
+        IdleConnTimeout     time.Duration
+        MatchCodes          statusCodeSet
+        MatchHeaders        headerMatchList
+        MatchBody           stringList
+        MatchLogic          string
+        MatchCmd            string
+        MatchCmdWorkers     int
+        MatchCmdTimeout     time.Duration
+        FilterDefault       bool
+        DefaultSignatures   string
+        WorkerStats         bool
+        Group               bool
+        StreamingLatency    bool
+        MinTLS              string
+        RequireValidTLS     bool
+        ReportInvalidTLS    bool
+        MaxBody             int64
+        SummaryFormat       string
+        Ordered             bool
+        Retries             int
+        RetryBudget         int
+        CacheBust           bool
+        TCPCheck            bool
+        InputFormat         string
+        InputField          string
+        InputDelimiter      string
+        SelfTest            bool
+        Compare             bool
+        SelfTestRequests    int
+
+        minTLSVersion     uint16
+        summaryTemplate   *template.Template
+        defaultSignatures []string
+        proxyURL          *url.URL
+        matchers          []Matcher
+        requestBody       []byte
+        scope             *scope
+        localAddrs        []net.IP
+}
+
+// stringList collects the values of a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+        return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+        *l = append(*l, value)
+        return nil
+}
+
+// headerMatch is a "Name: value" condition checked against response headers
+type headerMatch struct {
+        Name  string
+        Value string
+}
+
+func (m headerMatch) String() string {
+        return m.Name + ": " + m.Value
+}
+
+// headerMatchList collects repeated -match-header flags
+type headerMatchList []headerMatch
+
+func (l *headerMatchList) String() string {
+        parts := make([]string, len(*l))
+        for i, m := range *l {
+                parts[i] = m.String()
+        }
+        return strings.Join(parts, ", ")
+}
+
+func (l *headerMatchList) Set(value string) error {
+        name, expected, ok := strings.Cut(value, ":")
+        name = strings.TrimSpace(name)
+        if !ok || name == "" {
+                return fmt.Errorf("expected \"Name: value\", got %q", value)
+        }
+        *l = append(*l, headerMatch{Name: name, Value: strings.TrimSpace(expected)})
+        return nil
+}
+
+// parseFlags reads the command-line flags into a Config
+func parseFlags() *Config {
+        cfg := &Config{}
+        flag.Var(&cfg.Domains, "d", "Domain to scan, alone or merged with the host file (repeatable)")
+        flag.Var(&cfg.Domains, "domain", "Same as -d")
+        flag.Var(&cfg.Scope, "scope", "Only scan hosts under these domains or in these CIDRs, comma-separated (repeatable)")
+        flag.IntVar(&cfg.Limit, "limit", 0, "Only scan the first N domains after deduplication, e.g. to try out filters (0 = all)")
+        flag.BoolVar(&cfg.WWW, "www", false, "Also probe the www. form of each domain, or the bare form of www. domains")
+        flag.StringVar(&cfg.Wordlist, "wordlist", "", "Substitute each word for FUZZ in input lines and -base domains")
+        flag.Var(&cfg.Bases, "base", "Base domain to brute-force subdomains of with -wordlist (repeatable)")
+        flag.IntVar(&cfg.Workers, "workers", 0, "Number of concurrent workers (0 = ask interactively)")
+        flag.IntVar(&cfg.MaxWorkers, "max-workers", 0, "Adapt concurrency to failure rate and latency, up to this many workers\n"+
+                "(-workers sets the starting point; 0 = fixed -workers)")
+        flag.IntVar(&cfg.MinWorkers, "min-workers", 1, "Fewest workers adaptive concurrency backs off to")
+        flag.DurationVar(&cfg.Timeout, "timeout", connectionTimeout, "Timeout for connecting and for each request")
+        flag.StringVar(&cfg.Proxy, "proxy", "", "Send requests through this proxy URL (http, https or socks5)")
+        flag.Var(&cfg.LocalAddrs, "local-addr", "Local source IP to connect from, rotated per connection when repeated")
+        flag.StringVar(&cfg.Method, "method", http.MethodGet, "HTTP method for each request, e.g. POST")
+        flag.StringVar(&cfg.Body, "body", "", "Request body for -method POST, PUT, PATCH or DELETE (@file reads it from a file)")
+        flag.StringVar(&cfg.ContentType, "content-type", "", "Content-Type header sent with -body")
+        flag.DurationVar(&cfg.Ramp, "ramp", 0, "Start workers gradually over this long instead of all at once, e.g. 30s")
+        flag.BoolVar(&cfg.NoColor, "no-color", false, "Disable colored output")
+        flag.BoolVar(&cfg.NoBanner, "no-banner", false, "Do not print the banner")
+        flag.BoolVar(&cfg.Silent, "silent", false, "Print only matched domains, hiding progress lines and summaries")
+        flag.BoolVar(&cfg.StdoutMatchesOnly, "stdout-matches-only", false, "Print only matched domains on stdout, everything else on stderr")
+        flag.BoolVar(&cfg.Pipe, "pipe", false, "Shortcut for -no-banner -no-color -silent -stdout-matches-only;\n"+
+                "any of those flags given explicitly overrides the bundle")
+        flag.StringVar(&cfg.OutputForm, "output-form", "text", "Result line format: text, grep for plain \"host:port status category\" lines, or json for one JSON object per line")
+        flag.DurationVar(&cfg.StatsInterval, "stats-interval", 5*time.Second, "How often to print a stats line to stderr in -silent or grep output (0 = never)")
+        flag.BoolVar(&cfg.Stats, "stats", false, "Always print stats lines, even in text output or when stderr is not a terminal")
+        flag.BoolVar(&cfg.Verbose, "v", false, "Verbose output (show resolved IPs and body sizes)")
+        flag.BoolVar(&cfg.Append, "append", false, "Append to the -o file instead of overwriting it")
+        flag.StringVar(&cfg.InputFormat, "input-format", "plain", "Input format: plain (one domain per line), csv (domain,tag lines) or json (JSON lines)")
+        flag.StringVar(&cfg.InputField, "input-field", "host", "Field holding the domain when -input-format is json")
+        flag.StringVar(&cfg.InputDelimiter, "input-delimiter", ",", "Separator between domain and tag when -input-format is csv")
+        flag.StringVar(&cfg.Output, "o", "", "Write successful domains to this file ({file} expands to each input file)")
+        flag.IntVar(&cfg.Retries, "retries", retryAttempts, "Number of times to retry a failed request")
+        flag.BoolVar(&cfg.TCPCheck, "tcp-check", false, "Check that the port accepts TCP connections before sending HTTP (skipped with -proxy)")
+        flag.IntVar(&cfg.RetryBudget, "retry-budget", 0, "Maximum retries across the whole scan (0 = unlimited)")
+        flag.BoolVar(&cfg.CacheBust, "cache-bust", false, "Append a random query parameter to each request to bypass caches")
+        flag.BoolVar(&cfg.SecondPass, "second-pass", false, "Re-check domains whose request failed with an error once after the main scan")
+        flag.IntVar(&cfg.SecondPassWorkers, "second-pass-workers", 0, "Worker count for the second pass (0 = same as main scan)")
+        flag.DurationVar(&cfg.MaxDuration, "max-duration", 0, "Stop the whole scan after this long, e.g. 10m (0 = no limit)")
+        flag.Float64Var(&cfg.AbortFailRate, "abort-on-fail-rate", 0, "Abort when this fraction of the last 100 requests failed with an error (e.g. 0.9, 0 = never)")
+        flag.IntVar(&cfg.MaxIdleConns, "max-idle", 500, "Maximum idle connections kept across all hosts")
+        flag.IntVar(&cfg.MaxIdleConnsPerHost, "max-idle-per-host", 100, "Maximum idle connections kept per host")
+        flag.DurationVar(&cfg.IdleConnTimeout, "idle-timeout", 10*time.Second, "How long an idle connection is kept open")
+        flag.Var(&cfg.MatchCodes, "match-code", "Only count these status codes, e.g. 200,301 (repeatable; default any of 1-500)")
+        flag.Var(&cfg.MatchBody, "match-body", "Only count hosts whose body contains this text (repeatable)")
+        flag.StringVar(&cfg.MatchCmd, "match-cmd", "", "Shell command run per response; exit status 0 counts as a match\n"+
+                "(gets HOSTHUNTER_MATCH_URL, HOSTHUNTER_MATCH_DOMAIN, HOSTHUNTER_MATCH_STATUS and HOSTHUNTER_MATCH_HEADER_<NAME>, body on stdin)")
+        flag.IntVar(&cfg.MatchCmdWorkers, "match-cmd-workers", runtime.NumCPU(), "Maximum -match-cmd commands running at once")
+        flag.DurationVar(&cfg.MatchCmdTimeout, "match-cmd-timeout", 10*time.Second, "Time limit for each -match-cmd command")
+        flag.StringVar(&cfg.MatchLogic, "match-logic", "and", "How -match-code, -match-header and -match-body combine: and, or\n"+
+                "(e.g. -match-logic or -match-code 200 -match-body login matches status 200 OR a body containing \"login\")")
+        flag.Var(&cfg.MatchHeaders, "match-header", "Only count hosts whose header contains a value, e.g. \"Server: nginx\" (repeatable)")
+        flag.BoolVar(&cfg.FilterDefault, "filter-default", false, "Don't count hosts serving default or parked pages as successful")
+        flag.StringVar(&cfg.DefaultSignatures, "default-signatures", "", "File of extra default-page signatures for -filter-default, one per line")
+        flag.BoolVar(&cfg.StreamingLatency, "streaming-latency", false, "Estimate response time percentiles in fixed memory instead of storing every duration")
+        flag.BoolVar(&cfg.Group, "group", false, "List successful domains as a tree under their registered parent domain")
+        flag.BoolVar(&cfg.WorkerStats, "worker-stats", false, "Print per-worker domain counts and busy time at the end")
+        flag.StringVar(&cfg.MinTLS, "min-tls", "", "Minimum TLS version to accept: 1.0, 1.1, 1.2 or 1.3 (default Go's default)")
+        flag.BoolVar(&cfg.RequireValidTLS, "require-valid-tls", false, "Verify certificates and fail hosts with invalid ones")
+        flag.BoolVar(&cfg.ReportInvalidTLS, "report-invalid-tls", false, "Accept invalid certificates but tag hosts that present them")
+        flag.Int64Var(&cfg.MaxBody, "max-body", 512*1024, "Maximum response body bytes read for body inspection")
+        flag.StringVar(&cfg.SummaryFormat, "summary-format", "", "Go template for the summary, e.g. '{{.Successful}}/{{.Total}} in {{.Duration}}'\n"+
+                "(fields: .Name .Total .Scanned .Successful .Failed .NetworkFailures .Unmatched .Recovered .WWWVariants .WWWMatches .CmdMatches .OutOfScope .RetriesUsed .RetryBudget .StatusCounts .Duration .Throughput .AverageTime .P50 .P90 .P99 .MaxTime .Interrupted .AbortReason .Unscanned)")
+        flag.BoolVar(&cfg.Ordered, "ordered", false, "Print results in input order instead of completion order")
+        flag.BoolVar(&cfg.Compare, "compare", false, "Compare two -output-form json result files given as arguments, without scanning")
+        flag.BoolVar(&cfg.SelfTest, "self-test", false, "Benchmark against a local in-process server instead of scanning")
+        flag.IntVar(&cfg.SelfTestRequests, "self-test-requests", 1000, "Number of requests sent by -self-test")
+        flag.StringVar(&cfg.Serve, "serve", "", "Serve live results over HTTP on this address (e.g. :8080)")
+        flag.StringVar(&cfg.Webhook, "webhook", "", "POST the final summary as JSON to this URL")
+
+        flag.Usage = func() {
+                fmt.Fprintf(os.Stderr, "%sUsage: %s [flags] [hostfile...]%s\n", Green, os.Args[0], Reset)
+                flag.PrintDefaults()
+                fmt.Fprintf(os.Stderr, "\nEvery flag can also be set through a %s<FLAG> environment variable, e.g.\n"+
+                        "%sWORKERS=50 or %sNO_COLOR=true. Precedence: defaults < environment < flags.\n", envPrefix, envPrefix, envPrefix)
+        }
+
+        // Environment values act as defaults that command-line flags override
+        flag.Parse()
+        err := applyEnv()
+        if err == nil {
+                cfg.applyPipe()
+                err = cfg.validate()
+        }
+        if err != nil {
+                fmt.Fprintf(os.Stderr, "%v\n", err)
+                flag.Usage()
+                os.Exit(2)
+        }
+        return cfg
+}
+
+// envPrefix is prepended to a flag's name to form its environment variable
+const envPrefix = "HOSTHUNTER_"
+
+// applyEnv sets every flag that has a matching HOSTHUNTER_ environment variable
+// and was not given on the command line. It runs after flag.Parse so that
+// repeatable flags such as -scope are replaced by the command line rather
+// than appended to. Variables that do not correspond to a flag are ignored.
+func applyEnv() error {
+        // Aliases such as -d and -domain share a value, so track values, not names
+        onCommandLine := make(map[flag.Value]bool)
+        flag.Visit(func(f *flag.Flag) {
+                onCommandLine[f.Value] = true
+        })
+
+        var err error
+        flag.VisitAll(func(f *flag.Flag) {
+                name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+                value, ok := os.LookupEnv(name)
+                if !ok || err != nil || onCommandLine[f.Value] {
+                        return
+                }
+                if setErr := flag.Set(f.Name, value); setErr != nil {
+                        err = fmt.Errorf("invalid value %q for %s: %v", value, name, setErr)
+                }
+        })
+        return err
+}
+
+// applyPipe expands -pipe into its individual flags, leaving alone any of
+// them that were set explicitly on the command line
+func (cfg *Config) applyPipe() {
+        if !cfg.Pipe {
+                return
+        }
+        set := make(map[string]bool)
+        flag.Visit(func(f *flag.Flag) {
+                set[f.Name] = true
+        })
+
+        bundle := map[string]*bool{
+                "no-banner":           &cfg.NoBanner,
+                "no-color":            &cfg.NoColor,
+                "silent":              &cfg.Silent,
+                "stdout-matches-only": &cfg.StdoutMatchesOnly,
+        }
+        for name, value := range bundle {
+                if !set[name] {
+                        *value = true
+                }
+        }
+}
+
+// validate checks flag values and derives the settings computed from them
+func (cfg *Config) validate() error {
+        if cfg.MinTLS != "" {
+                version, ok := tlsVersions[cfg.MinTLS]
+                if !ok {
+                        return fmt.Errorf("invalid -min-tls %q: expected 1.0, 1.1, 1.2 or 1.3", cfg.MinTLS)
+                }
+                cfg.minTLSVersion = version
+        }
+        if cfg.Compare && flag.NArg() != 2 {
+                return fmt.Errorf("-compare needs exactly two result files: old.json new.json")
+        }
+        if cfg.MaxWorkers > 0 && (cfg.MinWorkers < 1 || cfg.MinWorkers > cfg.MaxWorkers) {
+                return fmt.Errorf("-min-workers must be between 1 and -max-workers")
+        }
+        if cfg.Limit < 0 {
+                return fmt.Errorf("-limit cannot be negative")
+        }
+        if len(cfg.Bases) > 0 && cfg.Wordlist == "" {
+                return fmt.Errorf("-base requires -wordlist")
+        }
+        if cfg.MatchLogic != "and" && cfg.MatchLogic != "or" {
+                return fmt.Errorf("invalid -match-logic %q: expected and or or", cfg.MatchLogic)
+        }
+        if cfg.MatchCmd != "" && (cfg.MatchCmdWorkers <= 0 || cfg.MatchCmdTimeout <= 0) {
+                return fmt.Errorf("-match-cmd-workers and -match-cmd-timeout must be positive")
+        }
+        cfg.matchers = buildMatchers(cfg)
+
+        if cfg.OutputForm != "text" && cfg.OutputForm != "grep" && cfg.OutputForm != "json" {
+                return fmt.Errorf("invalid -output-form %q: expected text, grep or json", cfg.OutputForm)
+        }
+        if cfg.InputFormat != "plain" && cfg.InputFormat != "csv" && cfg.InputFormat != "json" {
+                return fmt.Errorf("invalid -input-format %q: expected plain, csv or json", cfg.InputFormat)
+        }
+        if cfg.InputDelimiter == "" {
+                return fmt.Errorf("-input-delimiter cannot be empty")
+        }
+
+        format := cfg.SummaryFormat
+        if format == "" {
+                format = defaultSummaryFormat
+        }
+        tmpl, err := parseSummaryTemplate(format)
+        if err != nil {
+                return fmt.Errorf("invalid -summary-format: %v", err)
+        }
+        cfg.summaryTemplate = tmpl
+
+        if cfg.SelfTest && cfg.SelfTestRequests <= 0 {
+                return fmt.Errorf("-self-test-requests must be positive")
+        }
+        if cfg.FilterDefault {
+                signatures, err := loadSignatures(cfg.DefaultSignatures)
+                if err != nil {
+                        return fmt.Errorf("unable to load -default-signatures: %v", err)
+                }
+                cfg.defaultSignatures = signatures
+        }
+
+        if cfg.Timeout <= 0 {
+                return fmt.Errorf("-timeout must be positive")
+        }
+        if cfg.Proxy != "" {
+                proxyURL, err := url.Parse(cfg.Proxy)
+                if err != nil || proxyURL.Host == "" {
+                        return fmt.Errorf("invalid -proxy %q", cfg.Proxy)
+                }
+                cfg.proxyURL = proxyURL
+        }
+        if len(cfg.Scope) > 0 {
+                s, err := parseScope(cfg.Scope)
+                if err != nil {
+                        return err
+                }
+                // Through a proxy the dialed address is the proxy's, so only
+                // a direct connection can be checked where it goes
+                s.checkDial = len(s.networks) > 0 && cfg.proxyURL == nil
+                cfg.scope = s
+        }
+        if len(cfg.LocalAddrs) > 0 {
+                addrs, err := parseLocalAddrs(cfg.LocalAddrs)
+                if err != nil {
+                        return err
+                }
+                cfg.localAddrs = addrs
+        }
+        if err := cfg.validateRequest(); err != nil {
+                return err
+        }
+        if cfg.MaxBody <= 0 {
+                return fmt.Errorf("-max-body must be positive")
+        }
+        if cfg.RequireValidTLS && cfg.ReportInvalidTLS {
+                return fmt.Errorf("-require-valid-tls and -report-invalid-tls cannot be combined")
+        }
+        return nil
+}
+
+// bodyMethods are the methods that may carry a -body
+var bodyMethods = map[string]bool{
+        http.MethodPost:   true,
+        http.MethodPut:    true,
+        http.MethodPatch:  true,
+        http.MethodDelete: true,
+}
+
+// validateRequest checks -method, -body and -content-type and loads an @file body
+func (cfg *Config) validateRequest() error {
+        cfg.Method = strings.ToUpper(strings.TrimSpace(cfg.Method))
+        if cfg.Method == "" || strings.ContainsAny(cfg.Method, " \t/") {
+                return fmt.Errorf("invalid -method %q", cfg.Method)
+        }
+        if cfg.Body == "" {
+                if cfg.ContentType != "" {
+                        return fmt.Errorf("-content-type requires -body")
+                }
+                return nil
+        }
+        if !bodyMethods[cfg.Method] {
+                return fmt.Errorf("-body cannot be sent with -method %s; use POST, PUT, PATCH or DELETE", cfg.Method)
+        }
+
+        if path, ok := strings.CutPrefix(cfg.Body, "@"); ok {
+                body, err := os.ReadFile(path)
+                if err != nil {
+                        return fmt.Errorf("unable to read -body file: %v", err)
+                }
+                cfg.requestBody = body
+        } else {
+                cfg.requestBody = []byte(cfg.Body)
+        }
+        return nil
+}
+
+// tlsVersions maps -min-tls values to their crypto/tls constants
+var tlsVersions = map[string]uint16{
+        "1.0": tls.VersionTLS10,
+        "1.1": tls.VersionTLS11,
+        "1.2": tls.VersionTLS12,
+        "1.3": tls.VersionTLS13,
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+        "bufio"
+        "bytes"
+        "os"
+        "strings"
+)
+
+// defaultPageSignatures are snippets of stock web server and parking pages
+// that show a host is up but not actually serving anything
+var defaultPageSignatures = []string{
+        "<h1>It works!</h1>",
+        "Apache2 Ubuntu Default Page",
+        "Apache2 Debian Default Page",
+        "Test Page for the Apache HTTP Server",
+        "Welcome to nginx!",
+        "Welcome to nginx on",
+        "IIS Windows Server",
+        "Internet Information Services",
+        "Welcome to CentOS",
+        "Welcome to OpenResty!",
+        "Welcome to Caddy",
+        "lighttpd server is running",
+        "Default Web Site Page",
+        "This domain is parked",
+        "This domain name is parked",
+        "domain is for sale",
+        "Future home of something quite cool",
+}
+
+// loadSignatures returns the built-in signatures plus any read from path, lowercased
+func loadSignatures(path string) ([]string, error) {
+        signatures := append([]string(nil), defaultPageSignatures...)
+        if path != "" {
+                file, err := os.Open(path)
+                if err != nil {
+                        return nil, err
+                }
+                defer file.Close()
+
+                scanner := bufio.NewScanner(file)
+                for scanner.Scan() {
+                        if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+                                signatures = append(signatures, line)
+                        }
+                }
+                if err := scanner.Err(); err != nil {
+                        return nil, err
+                }
+        }
+
+        for i, sig := range signatures {
+                signatures[i] = strings.ToLower(sig)
+        }
+        return signatures, nil
+}
+
+// isDefaultPage reports whether the body contains any of the lowercased signatures
+func isDefaultPage(body []byte, signatures []string) bool {
+        lower := bytes.ToLower(body)
+        for _, sig := range signatures {
+                if bytes.Contains(lower, []byte(sig)) {
+                        return true
+                }
+        }
+        return false
+}
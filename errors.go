@@ -0,0 +1,58 @@
+package main
+
+import (
+        "context"
+        "crypto/tls"
+        "crypto/x509"
+        "errors"
+        "net"
+        "strings"
+        "syscall"
+)
+
+// Failure categories recorded on a Result
+const (
+        CategoryDNS     = "dns"
+        CategoryTimeout = "timeout"
+        CategoryRefused = "refused"
+        CategoryReset   = "reset"
+        CategoryTLS     = "tls"
+        CategoryTCP     = "tcp"
+        CategoryNetwork = "network"
+)
+
+// categorizeError classifies a request error so failures can be told apart
+func categorizeError(err error) string {
+        var dnsErr *net.DNSError
+        var netErr net.Error
+        var certErr *tls.CertificateVerificationError
+        var alertErr tls.AlertError
+        var recordErr tls.RecordHeaderError
+        var unknownAuthErr x509.UnknownAuthorityError
+        var hostnameErr x509.HostnameError
+        var invalidCertErr x509.CertificateInvalidError
+
+        switch {
+        case errors.As(err, &dnsErr):
+                return CategoryDNS
+        case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recordErr),
+                errors.As(err, &unknownAuthErr), errors.As(err, &hostnameErr), errors.As(err, &invalidCertErr):
+                return CategoryTLS
+        case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+                return CategoryTimeout
+        case errors.Is(err, syscall.ECONNREFUSED):
+                return CategoryRefused
+        case isConnReset(err):
+                return CategoryReset
+        case strings.Contains(err.Error(), "tls:"):
+                // Version and handshake mismatches are plain errors in crypto/tls
+                return CategoryTLS
+        default:
+                return CategoryNetwork
+        }
+}
+
+// isConnReset reports whether err is a connection reset or broken pipe
+func isConnReset(err error) bool {
+        return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+        "bufio"
+        "context"
+        "os"
+        "strings"
+)
+
+// fuzzPlaceholder marks where each wordlist entry goes in a -wordlist template
+const fuzzPlaceholder = "FUZZ"
+
+// fuzzTargets generates domains by substituting every wordlist entry into
+// each template. The wordlist is streamed from disk rather than held in memory.
+type fuzzTargets struct {
+        wordlist  string
+        templates []string
+        total     int
+}
+
+// newFuzzTargets counts the domains the templates expand to, so progress
+// can be reported against the real total before generation starts
+func newFuzzTargets(wordlist string, templates []string) (*fuzzTargets, error) {
+        f := &fuzzTargets{wordlist: wordlist, templates: templates}
+        err := f.eachWord(func(word string) bool {
+                for _, template := range templates {
+                        if validDomain(expandTemplate(template, word)) {
+                                f.total++
+                        }
+                }
+                return true
+        })
+        return f, err
+}
+
+// feed sends the generated domains to the channel, numbering them from first.
+// It stops after total domains, which -limit may have lowered.
+func (f *fuzzTargets) feed(ctx context.Context, first int, domains chan<- Target) {
+        index := first
+        f.eachWord(func(word string) bool {
+                for _, template := range f.templates {
+                        if index-first >= f.total {
+                                return false
+                        }
+                        domain := expandTemplate(template, word)
+                        if _, err := asciiURL(domain); err != nil {
+                                continue
+                        }
+                        select {
+                        case <-ctx.Done():
+                                return false
+                        case domains <- Target{Index: index, Domain: domain}:
+                                index++
+                        }
+                }
+                return true
+        })
+}
+
+// eachWord calls fn for every non-blank, non-comment wordlist line until fn returns false
+func (f *fuzzTargets) eachWord(fn func(word string) bool) error {
+        file, err := os.Open(f.wordlist)
+        if err != nil {
+                return err
+        }
+        defer file.Close()
+
+        scanner := bufio.NewScanner(file)
+        for lineNum := 1; scanner.Scan(); lineNum++ {
+                word := scanner.Text()
+                if lineNum == 1 {
+                        word = strings.TrimPrefix(word, utf8BOM)
+                }
+                word = strings.TrimSpace(word)
+                // A word can only fill a hostname label, so anything with spaces is skipped
+                if word == "" || strings.HasPrefix(word, "#") || strings.ContainsAny(word, " \t") {
+                        continue
+                }
+                if !fn(word) {
+                        return nil
+                }
+        }
+        return scanner.Err()
+}
+
+// expandTemplate puts word in place of the FUZZ placeholder
+func expandTemplate(template, word string) string {
+        return strings.ReplaceAll(template, fuzzPlaceholder, word)
+}
+
+// splitTemplates separates FUZZ templates from plain domains
+func splitTemplates(domainsList []string) (plain, templates []string) {
+        for _, domain := range domainsList {
+                if strings.Contains(domain, fuzzPlaceholder) {
+                        templates = append(templates, domain)
+                } else {
+                        plain = append(plain, domain)
+                }
+        }
+        return plain, templates
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+        "fmt"
+        "net"
+        "sort"
+
+        "golang.org/x/net/publicsuffix"
+)
+
+// registeredDomain returns the domain a result belongs to, e.g. example.co.uk
+// for api.example.co.uk. IP addresses and unknown suffixes group by host.
+func registeredDomain(result Result) string {
+        hostPort, err := targetHostPort(result.Domain)
+        if err != nil {
+                return result.Domain
+        }
+        host, _, err := net.SplitHostPort(hostPort)
+        if err != nil {
+                return hostPort
+        }
+        if net.ParseIP(host) != nil {
+                return host
+        }
+        parent, err := publicsuffix.EffectiveTLDPlusOne(host)
+        if err != nil {
+                return host
+        }
+        return parent
+}
+
+// printGroups prints the successful results as a tree under their registered domains
+func (sc *StatusChecker) printGroups() {
+        fmt.Fprintf(infoOut, "\n%s----● Successful Domains by Parent ●----%s\n", Magenta, Reset)
+
+        groups := make(map[string][]Result)
+        for _, result := range sc.matchedResults {
+                parent := registeredDomain(result)
+                groups[parent] = append(groups[parent], result)
+        }
+        parents := make([]string, 0, len(groups))
+        for parent := range groups {
+                parents = append(parents, parent)
+        }
+        sort.Strings(parents)
+
+        for _, parent := range parents {
+                results := groups[parent]
+                sort.Slice(results, func(i, j int) bool { return results[i].Domain < results[j].Domain })
+
+                fmt.Fprintf(infoOut, "%s%s%s (%d)\n", Cyan, parent, Reset, len(results))
+                for i, result := range results {
+                        branch := "├──"
+                        if i == len(results)-1 {
+                                branch = "└──"
+                        }
+                        fmt.Fprintf(infoOut, "    %s %s%-50s %d%s\n", branch, statusColor(result.StatusCode), result.Domain, result.StatusCode, Reset)
+                }
+        }
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+        "bufio"
+        "compress/gzip"
+        "encoding/json"
+        "fmt"
+        "io"
+        "net"
+        "net/url"
+        "os"
+        "strings"
+        "unicode/utf8"
+
+        "golang.org/x/net/idna"
+)
+
+// utf8BOM is the byte order mark some editors put at the start of UTF-8 files
+const utf8BOM = "\ufeff"
+
+// maxLineSize bounds a single input line, leaving room for long JSON records
+const maxLineSize = 1024 * 1024
+
+// readDomains reads one domain per line, skipping blanks, # comments and invalid hostnames,
+// and returns the tags given with -input-format csv keyed by domain.
+// Gzip-compressed input is detected by its magic bytes and decompressed.
+func readDomains(r io.Reader, cfg *Config) ([]string, map[string]string, error) {
+        r, err := maybeGunzip(r)
+        if err != nil {
+                return nil, nil, err
+        }
+
+        var domainsList []string
+        var tags map[string]string
+        scanner := bufio.NewScanner(r)
+        scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+        for lineNum := 1; scanner.Scan(); lineNum++ {
+                line := scanner.Text()
+                // Files saved on Windows may start with a BOM and end lines with CRLF
+                if lineNum == 1 {
+                        line = strings.TrimPrefix(line, utf8BOM)
+                }
+                line = strings.TrimSuffix(line, "\r")
+
+                // Skip blanks and comments, including the run headers -append writes
+                if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+                        continue
+                }
+                domain, tag, err := parseLine(line, cfg)
+                if err != nil {
+                        fmt.Fprintf(os.Stderr, "%sSkipping line %d: %v%s\n", Magenta, lineNum, err, Reset)
+                        continue
+                }
+                if !validDomain(domain) {
+                        continue
+                }
+                domainsList = append(domainsList, domain)
+                if _, seen := tags[domain]; tag != "" && !seen {
+                        if tags == nil {
+                                tags = make(map[string]string)
+                        }
+                        tags[domain] = tag
+                }
+        }
+        return domainsList, tags, scanner.Err()
+}
+
+// validDomain reports whether the domain can be requested, warning when it cannot
+func validDomain(domain string) bool {
+        if _, err := asciiURL(domain); err != nil {
+                fmt.Fprintf(os.Stderr, "%sSkipping invalid domain %q: %v%s\n", Magenta, domain, err, Reset)
+                return false
+        }
+        return true
+}
+
+// inputFile is one list of domains to scan, reported with its own summary
+type inputFile struct {
+        path     string
+        domains  []string
+        tags     map[string]string
+        variants map[string]bool // domains added by -www
+        fuzz     *fuzzTargets
+}
+
+// total returns the number of domains the input will scan, generated ones included
+func (in inputFile) total() int {
+        if in.fuzz == nil {
+                return len(in.domains)
+        }
+        return len(in.domains) + in.fuzz.total
+}
+
+// cmdlineInput names the input holding -d domains when they are not merged into a file
+const cmdlineInput = "cmdline"
+
+// loadInputs reads every host file and adds the -d domains. With a single
+// file the -d domains are merged into it; otherwise they form their own input.
+// Files that cannot be read abort the program, empty ones are skipped.
+func loadInputs(cfg *Config, paths []string) []inputFile {
+        var inputs []inputFile
+        for _, path := range paths {
+                file, err := os.Open(path)
+                if err != nil {
+                        fmt.Fprintf(os.Stderr, "%sError: Unable to open file - %v%s\n", Magenta, err, Reset)
+                        os.Exit(1)
+                }
+                domainsList, tags, err := readDomains(file, cfg)
+                file.Close()
+                if err != nil {
+                        fmt.Fprintf(os.Stderr, "%sError: Unable to read %s - %v%s\n", Magenta, path, err, Reset)
+                        os.Exit(1)
+                }
+
+                if len(domainsList) == 0 && !(len(paths) == 1 && len(cfg.Domains)+len(cfg.Bases) > 0) {
+                        fmt.Fprintf(os.Stderr, "%sNo domains found in %s.%s\n", Magenta, path, Reset)
+                        continue
+                }
+                inputs = append(inputs, inputFile{path: path, domains: domainsList, tags: tags})
+        }
+
+        if len(cfg.Domains)+len(cfg.Bases) > 0 {
+                var extra []string
+                for _, domain := range cfg.Domains {
+                        if domain = strings.TrimSpace(domain); domain != "" && validDomain(domain) {
+                                extra = append(extra, domain)
+                        }
+                }
+                for _, base := range cfg.Bases {
+                        if base = strings.Trim(strings.TrimSpace(base), "."); base != "" {
+                                extra = append(extra, fuzzPlaceholder+"."+base)
+                        }
+                }
+                if len(paths) == 1 && len(inputs) == 1 {
+                        inputs[0].domains = dedupDomains(append(inputs[0].domains, extra...))
+                } else if len(extra) > 0 {
+                        inputs = append(inputs, inputFile{path: cmdlineInput, domains: dedupDomains(extra)})
+                }
+        }
+
+        if cfg.Wordlist != "" {
+                for i := range inputs {
+                        var templates []string
+                        inputs[i].domains, templates = splitTemplates(inputs[i].domains)
+                        if len(templates) == 0 {
+                                continue
+                        }
+                        fuzz, err := newFuzzTargets(cfg.Wordlist, templates)
+                        if err != nil {
+                                fmt.Fprintf(os.Stderr, "%sError: Unable to read wordlist - %v%s\n", Magenta, err, Reset)
+                                os.Exit(1)
+                        }
+                        inputs[i].fuzz = fuzz
+                }
+        }
+
+        if cfg.WWW {
+                for i := range inputs {
+                        addWWWVariants(&inputs[i])
+                }
+        }
+
+        if cfg.Limit > 0 {
+                // Repeated lines would otherwise use up the limit
+                for i := range inputs {
+                        inputs[i].domains = dedupDomains(inputs[i].domains)
+                }
+                inputs = limitInputs(inputs, cfg.Limit)
+        }
+
+        if len(inputs) == 1 && inputs[0].total() == 0 {
+                fmt.Fprintf(os.Stderr, "%sNo domains found.%s\n", Magenta, Reset)
+                return nil
+        }
+        return inputs
+}
+
+// addWWWVariants puts the www. form of each domain right after it, or the
+// bare form for www. domains, unless the input already has that form.
+// Variants share the tag of the domain they came from.
+func addWWWVariants(in *inputFile) {
+        seen := make(map[string]bool, len(in.domains))
+        for _, domain := range in.domains {
+                seen[strings.ToLower(domain)] = true
+        }
+
+        domains := make([]string, 0, 2*len(in.domains))
+        for _, domain := range in.domains {
+                domains = append(domains, domain)
+                variant, ok := wwwVariant(domain)
+                if !ok || seen[strings.ToLower(variant)] {
+                        continue
+                }
+                seen[strings.ToLower(variant)] = true
+                domains = append(domains, variant)
+
+                if in.variants == nil {
+                        in.variants = make(map[string]bool)
+                }
+                in.variants[variant] = true
+                if tag, ok := in.tags[domain]; ok {
+                        in.tags[variant] = tag
+                }
+        }
+        in.domains = domains
+}
+
+// wwwVariant adds or strips the www. prefix on the domain's host, keeping any
+// scheme, port and path. IP addresses have no variant.
+func wwwVariant(domain string) (string, bool) {
+        scheme, rest := "", domain
+        if i := strings.Index(domain, "://"); i >= 0 {
+                scheme, rest = domain[:i+3], domain[i+3:]
+        }
+        end := strings.IndexAny(rest, ":/?#")
+        if end < 0 {
+                end = len(rest)
+        }
+        host, suffix := rest[:end], rest[end:]
+        if host == "" || net.ParseIP(host) != nil {
+                return "", false
+        }
+
+        if bare, ok := strings.CutPrefix(strings.ToLower(host), "www."); ok {
+                if !strings.Contains(bare, ".") {
+                        return "", false // www.com has no sensible bare form
+                }
+                return scheme + host[len("www."):] + suffix, true
+        }
+        return scheme + "www." + host + suffix, true
+}
+
+// limitInputs keeps only the first limit domains across all inputs,
+// dropping inputs that no longer have anything to scan
+func limitInputs(inputs []inputFile, limit int) []inputFile {
+        remaining := limit
+        var kept []inputFile
+        for _, in := range inputs {
+                if remaining == 0 {
+                        break
+                }
+                if len(in.domains) >= remaining {
+                        in.domains, in.fuzz = in.domains[:remaining], nil
+                } else if in.fuzz != nil && in.total() > remaining {
+                        in.fuzz.total = remaining - len(in.domains)
+                }
+                remaining -= in.total()
+                kept = append(kept, in)
+        }
+        return kept
+}
+
+// dedupDomains removes repeated domains, keeping the first occurrence
+func dedupDomains(domains []string) []string {
+        seen := make(map[string]bool, len(domains))
+        unique := domains[:0]
+        for _, domain := range domains {
+                if !seen[domain] {
+                        seen[domain] = true
+                        unique = append(unique, domain)
+                }
+        }
+        return unique
+}
+
+// parseLine extracts the domain and its optional tag from one input line
+// according to -input-format
+func parseLine(line string, cfg *Config) (string, string, error) {
+        if cfg.InputFormat == "csv" {
+                domain, tag, _ := strings.Cut(line, cfg.InputDelimiter)
+                return strings.TrimSpace(domain), strings.TrimSpace(tag), nil
+        }
+        if cfg.InputFormat != "json" {
+                return strings.TrimSpace(line), "", nil
+        }
+
+        var record map[string]any
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+                return "", "", fmt.Errorf("malformed JSON: %v", err)
+        }
+        value, ok := record[cfg.InputField].(string)
+        if !ok || strings.TrimSpace(value) == "" {
+                return "", "", fmt.Errorf("missing string field %q", cfg.InputField)
+        }
+        return strings.TrimSpace(value), "", nil
+}
+
+// maybeGunzip wraps r in a gzip reader when the stream starts with the gzip magic bytes
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+        br := bufio.NewReader(r)
+        magic, _ := br.Peek(2)
+        if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+                return br, nil
+        }
+        return gzip.NewReader(br)
+}
+
+// asciiURL punycode-encodes an internationalized hostname inside a domain or URL,
+// leaving the scheme, port and path untouched
+func asciiURL(domain string) (string, error) {
+        rest := domain
+        scheme := ""
+        if i := strings.Index(rest, "://"); i >= 0 {
+                scheme, rest = rest[:i+3], rest[i+3:]
+        }
+
+        host, suffix := rest, ""
+        if i := strings.IndexAny(rest, ":/?#"); i >= 0 {
+                host, suffix = rest[:i], rest[i:]
+        }
+
+        // Plain ASCII hosts need no conversion
+        if !hasNonASCII(host) {
+                return domain, nil
+        }
+
+        encoded, err := idna.Lookup.ToASCII(host)
+        if err != nil {
+                return "", fmt.Errorf("malformed internationalized hostname: %v", err)
+        }
+        return scheme + encoded + suffix, nil
+}
+
+// hasNonASCII reports whether s contains any non-ASCII characters
+func hasNonASCII(s string) bool {
+        for i := 0; i < len(s); i++ {
+                if s[i] >= utf8.RuneSelf {
+                        return true
+                }
+        }
+        return false
+}
+
+// targetHostPort returns the host:port a domain or URL connects to,
+// defaulting the port from the scheme (https when none is given)
+func targetHostPort(target string) (string, error) {
+        if !strings.Contains(target, "://") {
+                target = "https://" + target
+        }
+        u, err := url.Parse(target)
+        if err != nil {
+                return "", err
+        }
+        if u.Host == "" {
+                return "", fmt.Errorf("no host in %q", target)
+        }
+
+        port := u.Port()
+        if port == "" {
+                port = "443"
+                if u.Scheme == "http" {
+                        port = "80"
+                }
+        }
+        return net.JoinHostPort(u.Hostname(), port), nil
+}
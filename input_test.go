@@ -0,0 +1,109 @@
+package main
+
+import (
+        "bytes"
+        "compress/gzip"
+        "os"
+        "path/filepath"
+        "reflect"
+        "strings"
+        "testing"
+)
+
+func TestASCIIURL(t *testing.T) {
+        tests := []struct {
+                name    string
+                domain  string
+                want    string
+                wantErr bool
+        }{
+                {"ascii unchanged", "https://example.com/path", "https://example.com/path", false},
+                {"cyrillic host", "https://пример.рф/path", "https://xn--e1afmkfd.xn--p1ai/path", false},
+                {"cjk host with port", "例子.测试:8443", "xn--fsqu00a.xn--0zwm56d:8443", false},
+                {"malformed host", "https://exa\u200dmple.com", "", true},
+        }
+        for _, tt := range tests {
+                t.Run(tt.name, func(t *testing.T) {
+                        got, err := asciiURL(tt.domain)
+                        if (err != nil) != tt.wantErr {
+                                t.Fatalf("asciiURL(%q) error = %v, wantErr %v", tt.domain, err, tt.wantErr)
+                        }
+                        if got != tt.want {
+                                t.Errorf("asciiURL(%q) = %q, want %q", tt.domain, got, tt.want)
+                        }
+                })
+        }
+}
+
+func TestReadDomainsGzip(t *testing.T) {
+        var buf bytes.Buffer
+        zw := gzip.NewWriter(&buf)
+        zw.Write([]byte("a.com\nb.com\n"))
+        zw.Close()
+
+        got, _, err := readDomains(&buf, &Config{InputFormat: "plain"})
+        if err != nil {
+                t.Fatalf("readDomains: %v", err)
+        }
+        if want := []string{"a.com", "b.com"}; !reflect.DeepEqual(got, want) {
+                t.Errorf("readDomains(gzip) = %q, want %q", got, want)
+        }
+}
+
+func TestReadDomainsPlain(t *testing.T) {
+        got, _, err := readDomains(strings.NewReader("a.com\n\nb.com\n"), &Config{InputFormat: "plain"})
+        if err != nil {
+                t.Fatalf("readDomains: %v", err)
+        }
+        if want := []string{"a.com", "b.com"}; !reflect.DeepEqual(got, want) {
+                t.Errorf("readDomains(plain) = %q, want %q", got, want)
+        }
+}
+
+func TestReadDomainsBOMAndCRLF(t *testing.T) {
+        got, _, err := readDomains(strings.NewReader("\ufeffa.com\r\nb.com\r\n"), &Config{InputFormat: "plain"})
+        if err != nil {
+                t.Fatalf("readDomains: %v", err)
+        }
+        if want := []string{"a.com", "b.com"}; !reflect.DeepEqual(got, want) {
+                t.Errorf("readDomains(BOM, CRLF) = %q, want %q", got, want)
+        }
+}
+
+func TestReadDomainsAppendedOutput(t *testing.T) {
+        path := filepath.Join(t.TempDir(), "live.txt")
+        if err := writeDomains(path, []string{"a.com"}, true); err != nil {
+                t.Fatal(err)
+        }
+        if err := writeDomains(path, []string{"b.com"}, true); err != nil {
+                t.Fatal(err)
+        }
+
+        file, err := os.Open(path)
+        if err != nil {
+                t.Fatal(err)
+        }
+        defer file.Close()
+        got, _, err := readDomains(file, &Config{InputFormat: "plain"})
+        if err != nil {
+                t.Fatalf("readDomains: %v", err)
+        }
+        if want := []string{"a.com", "b.com"}; !reflect.DeepEqual(got, want) {
+                t.Errorf("readDomains(appended output) = %q, want %q", got, want)
+        }
+}
+
+func TestLoadInputsLimitAfterDedup(t *testing.T) {
+        path := filepath.Join(t.TempDir(), "hosts.txt")
+        if err := os.WriteFile(path, []byte("a.com\na.com\nb.com\nc.com\n"), 0644); err != nil {
+                t.Fatal(err)
+        }
+
+        inputs := loadInputs(&Config{InputFormat: "plain", Limit: 2}, []string{path})
+        if len(inputs) != 1 {
+                t.Fatalf("loaded %d inputs, want 1", len(inputs))
+        }
+        if want := []string{"a.com", "b.com"}; !reflect.DeepEqual(inputs[0].domains, want) {
+                t.Errorf("-limit 2 kept %q, want %q", inputs[0].domains, want)
+        }
+}
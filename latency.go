@@ -0,0 +1,110 @@
+package main
+
+import (
+        "math"
+        "sort"
+        "time"
+)
+
+// latencyRecorder collects response durations and reports their quantiles
+type latencyRecorder interface {
+        record(d time.Duration)
+        merge(other latencyRecorder)
+        quantile(q float64) time.Duration
+        max() time.Duration
+}
+
+// newLatencyRecorder returns the exact recorder, or the fixed-memory
+// histogram when -streaming-latency is set
+func newLatencyRecorder(cfg *Config) latencyRecorder {
+        if cfg.StreamingLatency {
+                return &latencyHistogram{}
+        }
+        return &latencySamples{}
+}
+
+// latencySamples keeps every duration and computes exact quantiles
+type latencySamples struct {
+        durations []time.Duration
+        sorted    bool
+}
+
+func (s *latencySamples) record(d time.Duration) {
+        s.durations = append(s.durations, d)
+        s.sorted = false
+}
+
+func (s *latencySamples) merge(other latencyRecorder) {
+        s.durations = append(s.durations, other.(*latencySamples).durations...)
+        s.sorted = false
+}
+
+func (s *latencySamples) quantile(q float64) time.Duration {
+        if len(s.durations) == 0 {
+                return 0
+        }
+        if !s.sorted {
+                sort.Slice(s.durations, func(i, j int) bool { return s.durations[i] < s.durations[j] })
+                s.sorted = true
+        }
+        // Nearest-rank method
+        rank := int(math.Ceil(q*float64(len(s.durations)))) - 1
+        return s.durations[max(rank, 0)]
+}
+
+func (s *latencySamples) max() time.Duration {
+        return s.quantile(1)
+}
+
+// Histogram buckets grow by 2% each, starting at one microsecond, so any
+// quantile is reported within 2% of its true value using constant memory
+const (
+        histogramGrowth  = 1.02
+        histogramBuckets = 1200 // covers up to roughly six hours
+)
+
+// latencyHistogram approximates quantiles from log-scaled buckets
+type latencyHistogram struct {
+        counts  [histogramBuckets]int
+        total   int
+        longest time.Duration
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+        bucket := 0
+        if us := float64(d) / float64(time.Microsecond); us > 1 {
+                bucket = min(int(math.Log(us)/math.Log(histogramGrowth)), histogramBuckets-1)
+        }
+        h.counts[bucket]++
+        h.total++
+        h.longest = max(h.longest, d)
+}
+
+func (h *latencyHistogram) merge(other latencyRecorder) {
+        o := other.(*latencyHistogram)
+        for i, n := range o.counts {
+                h.counts[i] += n
+        }
+        h.total += o.total
+        h.longest = max(h.longest, o.longest)
+}
+
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+        if h.total == 0 {
+                return 0
+        }
+        rank := int(math.Ceil(q * float64(h.total)))
+        seen := 0
+        for i, n := range h.counts {
+                if seen += n; seen >= max(rank, 1) {
+                        // Report the bucket's upper bound, never more than the longest seen
+                        upper := time.Duration(math.Pow(histogramGrowth, float64(i+1)) * float64(time.Microsecond))
+                        return min(upper, h.longest)
+                }
+        }
+        return h.longest
+}
+
+func (h *latencyHistogram) max() time.Duration {
+        return h.longest
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+        "context"
+        "fmt"
+        "net"
+        "sync/atomic"
+)
+
+// contextDialer opens the connections used for scanning
+type contextDialer interface {
+        DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// rotatingDialer spreads connections across several source addresses,
+// picking the next -local-addr for each new connection
+type rotatingDialer struct {
+        dialers []*net.Dialer
+        next    atomic.Uint64
+}
+
+// newRotatingDialer returns a dialer per local address, each copied from base
+func newRotatingDialer(base net.Dialer, addrs []net.IP) *rotatingDialer {
+        d := &rotatingDialer{}
+        for _, ip := range addrs {
+                dialer := base
+                dialer.LocalAddr = &net.TCPAddr{IP: ip}
+                d.dialers = append(d.dialers, &dialer)
+        }
+        return d
+}
+
+// DialContext connects from the next address in turn. The dialer only tries
+// remote addresses of the same family as the local one.
+func (d *rotatingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+        i := d.next.Add(1) - 1
+        return d.dialers[i%uint64(len(d.dialers))].DialContext(ctx, network, address)
+}
+
+// parseLocalAddrs checks that every -local-addr is an IP assigned to a local interface
+func parseLocalAddrs(values []string) ([]net.IP, error) {
+        ifaceAddrs, err := net.InterfaceAddrs()
+        if err != nil {
+                return nil, fmt.Errorf("unable to list local interfaces: %v", err)
+        }
+
+        var addrs []net.IP
+        for _, value := range values {
+                ip := net.ParseIP(value)
+                if ip == nil {
+                        return nil, fmt.Errorf("invalid -local-addr %q: not an IP address", value)
+                }
+                if !isLocalIP(ip, ifaceAddrs) {
+                        return nil, fmt.Errorf("invalid -local-addr %q: not assigned to a local interface", value)
+                }
+                addrs = append(addrs, ip)
+        }
+        return addrs, nil
+}
+
+// isLocalIP reports whether ip is one of the interface addresses
+func isLocalIP(ip net.IP, ifaceAddrs []net.Addr) bool {
+        for _, addr := range ifaceAddrs {
+                if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+                        return true
+                }
+        }
+        return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+        "bytes"
+        "context"
+        "errors"
+        "fmt"
+        "net/http"
+        "os"
+        "os/exec"
+        "runtime"
+        "strconv"
+        "strings"
+        "time"
+)
+
+// cmdMatchDetail tags results matched by the -match-cmd command
+const cmdMatchDetail = "match-cmd"
+
+// cmdMatcher runs an external command for each response and treats exit
+// status 0 as a match. The response is described in HOSTHUNTER_MATCH_* environment
+// variables and the body, if read, is passed on stdin.
+type cmdMatcher struct {
+        command string
+        timeout time.Duration
+        slots   chan struct{} // bounds how many commands run at once
+}
+
+func newCmdMatcher(command string, workers int, timeout time.Duration) *cmdMatcher {
+        return &cmdMatcher{
+                command: command,
+                timeout: timeout,
+                slots:   make(chan struct{}, workers),
+        }
+}
+
+func (m *cmdMatcher) Match(resp *http.Response, body []byte) (bool, string) {
+        ctx := context.Background()
+        if resp.Request != nil {
+                ctx = resp.Request.Context()
+        }
+
+        select {
+        case m.slots <- struct{}{}:
+                defer func() { <-m.slots }()
+        case <-ctx.Done():
+                return false, ""
+        }
+
+        ctx, cancel := context.WithTimeout(ctx, m.timeout)
+        defer cancel()
+
+        cmd := shellCommand(ctx, m.command)
+        cmd.Env = append(os.Environ(), cmdEnv(resp)...)
+        cmd.Stdin = bytes.NewReader(body)
+        err := cmd.Run()
+        if err == nil {
+                return true, cmdMatchDetail
+        }
+
+        // A non-zero exit is a normal "no match"; anything else is worth reporting
+        var exitErr *exec.ExitError
+        if !errors.As(err, &exitErr) || ctx.Err() != nil {
+                if ctx.Err() != nil {
+                        err = fmt.Errorf("timed out after %v", m.timeout)
+                }
+                fmt.Fprintf(os.Stderr, "%sWarning: -match-cmd failed for %s - %v%s\n", Magenta, cmdTarget(resp), err, Reset)
+        }
+        return false, ""
+}
+
+// shellCommand runs command through the platform shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+        if runtime.GOOS == "windows" {
+                return exec.CommandContext(ctx, "cmd", "/C", command)
+        }
+        return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// cmdEnv describes the response for the -match-cmd command. Each header is
+// exported as HOSTHUNTER_MATCH_HEADER_<NAME>, with dashes turned into underscores.
+// The MATCH_ prefix keeps them apart from the HOSTHUNTER_<FLAG> settings.
+func cmdEnv(resp *http.Response) []string {
+        env := []string{
+                "HOSTHUNTER_MATCH_URL=" + cmdTarget(resp),
+                "HOSTHUNTER_MATCH_STATUS=" + strconv.Itoa(resp.StatusCode),
+        }
+        if resp.Request != nil {
+                env = append(env, "HOSTHUNTER_MATCH_DOMAIN="+resp.Request.URL.Hostname())
+        }
+        for name, values := range resp.Header {
+                key := "HOSTHUNTER_MATCH_HEADER_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+                env = append(env, key+"="+strings.Join(values, ", "))
+        }
+        return env
+}
+
+// cmdTarget returns the URL the response came from
+func cmdTarget(resp *http.Response) string {
+        if resp.Request == nil {
+                return ""
+        }
+        return resp.Request.URL.String()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+        "bytes"
+        "fmt"
+        "net/http"
+        "strconv"
+        "strings"
+)
+
+// Matcher decides whether a response counts as a match. The detail describes
+// what matched and is shown alongside the result; it may be empty.
+type Matcher interface {
+        Match(resp *http.Response, body []byte) (matched bool, detail string)
+}
+
+// statusMatcher matches the -match-code list, or any status from 1 to 500 when no codes are given
+type statusMatcher struct {
+        codes map[int]bool
+}
+
+func (m statusMatcher) Match(resp *http.Response, body []byte) (bool, string) {
+        if len(m.codes) == 0 {
+                return isSuccessStatus(resp.StatusCode), ""
+        }
+        return m.codes[resp.StatusCode], ""
+}
+
+// headerMatcher matches when a -match-header condition is found in the
+// response headers (case-insensitive substring on the value)
+type headerMatcher struct {
+        cond headerMatch
+}
+
+func (m headerMatcher) Match(resp *http.Response, body []byte) (bool, string) {
+        for _, value := range resp.Header.Values(m.cond.Name) {
+                if strings.Contains(strings.ToLower(value), strings.ToLower(m.cond.Value)) {
+                        return true, m.cond.String()
+                }
+        }
+        return false, ""
+}
+
+// bodyMatcher matches when the response body contains the text (case-insensitive)
+type bodyMatcher struct {
+        text string
+}
+
+func (m bodyMatcher) Match(resp *http.Response, body []byte) (bool, string) {
+        if bytes.Contains(bytes.ToLower(body), []byte(strings.ToLower(m.text))) {
+                return true, fmt.Sprintf("body %q", m.text)
+        }
+        return false, ""
+}
+
+// buildMatchers creates the matchers for the configured flags. With "or"
+// logic the default 1-500 status range is only used when nothing else is
+// configured, since it would otherwise match every response.
+func buildMatchers(cfg *Config) []Matcher {
+        var matchers []Matcher
+        if len(cfg.MatchCodes) > 0 || cfg.MatchLogic != "or" {
+                matchers = append(matchers, statusMatcher{codes: cfg.MatchCodes})
+        }
+        // Each header and body condition is its own matcher, so repeats follow -match-logic
+        for _, cond := range cfg.MatchHeaders {
+                matchers = append(matchers, headerMatcher{cond: cond})
+        }
+        for _, text := range cfg.MatchBody {
+                matchers = append(matchers, bodyMatcher{text: text})
+        }
+        // Last, so "and" logic only runs the command for otherwise matching responses
+        if cfg.MatchCmd != "" {
+                matchers = append(matchers, newCmdMatcher(cfg.MatchCmd, cfg.MatchCmdWorkers, cfg.MatchCmdTimeout))
+        }
+        if len(matchers) == 0 {
+                matchers = append(matchers, statusMatcher{})
+        }
+        return matchers
+}
+
+// matchResponse combines the matchers with "and" or "or" logic, returning
+// the details of the matchers that matched
+func matchResponse(matchers []Matcher, logic string, resp *http.Response, body []byte) (bool, []string) {
+        var details []string
+        matchedAny := false
+        for _, m := range matchers {
+                ok, detail := m.Match(resp, body)
+                if !ok {
+                        if logic != "or" {
+                                return false, nil
+                        }
+                        continue
+                }
+                matchedAny = true
+                if detail != "" {
+                        details = append(details, detail)
+                }
+        }
+        return matchedAny, details
+}
+
+// statusCodeSet collects -match-code values given as comma-separated lists
+type statusCodeSet map[int]bool
+
+func (s *statusCodeSet) String() string {
+        var codes []string
+        for code := range *s {
+                codes = append(codes, strconv.Itoa(code))
+        }
+        return strings.Join(codes, ",")
+}
+
+func (s *statusCodeSet) Set(value string) error {
+        if *s == nil {
+                *s = make(statusCodeSet)
+        }
+        for _, field := range strings.Split(value, ",") {
+                code, err := strconv.Atoi(strings.TrimSpace(field))
+                if err != nil || code < 100 || code > 999 {
+                        return fmt.Errorf("invalid status code %q", field)
+                }
+                (*s)[code] = true
+        }
+        return nil
+}
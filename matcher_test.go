@@ -0,0 +1,67 @@
+package main
+
+import (
+        "net/http"
+        "reflect"
+        "testing"
+)
+
+func TestMatchResponse(t *testing.T) {
+        resp := &http.Response{
+                StatusCode: 200,
+                Header:     http.Header{"Server": {"nginx/1.25"}, "X-Powered-By": {"PHP/8.2"}},
+        }
+        body := []byte("<title>Admin login</title>")
+
+        tests := []struct {
+                name        string
+                cfg         Config
+                wantMatched bool
+                wantBy      []string
+        }{
+                {
+                        name:        "and, all overlapping matchers hit",
+                        cfg:         Config{MatchLogic: "and", MatchCodes: statusCodeSet{200: true}, MatchBody: stringList{"login", "admin"}},
+                        wantMatched: true,
+                        wantBy:      []string{`body "login"`, `body "admin"`},
+                },
+                {
+                        name:        "and, one body text missing",
+                        cfg:         Config{MatchLogic: "and", MatchBody: stringList{"login", "dashboard"}},
+                        wantMatched: false,
+                },
+                {
+                        name:        "and, repeated headers must all match",
+                        cfg:         Config{MatchLogic: "and", MatchHeaders: headerMatchList{{"Server", "nginx"}, {"Server", "apache"}}},
+                        wantMatched: false,
+                },
+                {
+                        name:        "or, repeated headers need any",
+                        cfg:         Config{MatchLogic: "or", MatchHeaders: headerMatchList{{"Server", "nginx"}, {"Server", "apache"}}},
+                        wantMatched: true,
+                        wantBy:      []string{"Server: nginx"},
+                },
+                {
+                        name:        "or, status misses but body hits",
+                        cfg:         Config{MatchLogic: "or", MatchCodes: statusCodeSet{404: true}, MatchBody: stringList{"login"}},
+                        wantMatched: true,
+                        wantBy:      []string{`body "login"`},
+                },
+                {
+                        name:        "or, nothing hits",
+                        cfg:         Config{MatchLogic: "or", MatchCodes: statusCodeSet{404: true}, MatchBody: stringList{"dashboard"}},
+                        wantMatched: false,
+                },
+        }
+        for _, tt := range tests {
+                t.Run(tt.name, func(t *testing.T) {
+                        matched, by := matchResponse(buildMatchers(&tt.cfg), tt.cfg.MatchLogic, resp, body)
+                        if matched != tt.wantMatched {
+                                t.Fatalf("matched = %v, want %v", matched, tt.wantMatched)
+                        }
+                        if matched && !reflect.DeepEqual(by, tt.wantBy) {
+                                t.Errorf("matched by %q, want %q", by, tt.wantBy)
+                        }
+                })
+        }
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+        "bufio"
+        "encoding/json"
+        "fmt"
+        "io"
+        "net/http"
+        "os"
+        "path/filepath"
+        "sync"
+        "time"
+)
+
+// infoOut receives everything except the plain match list printed by -silent
+// and -stdout-matches-only: progress lines, summaries and section headers
+var infoOut io.Writer = os.Stdout
+
+// configureOutput routes informational output according to -silent,
+// -stdout-matches-only and -output-form
+func configureOutput(cfg *Config) {
+        switch {
+        case cfg.Silent:
+                infoOut = io.Discard
+        case cfg.StdoutMatchesOnly, cfg.OutputForm != "text":
+                infoOut = os.Stderr
+        }
+}
+
+// resultOut returns where a grep or json result line goes. Under -silent and
+// -stdout-matches-only stdout is kept for matches, so the rest goes to infoOut
+func resultOut(cfg *Config, matched bool) io.Writer {
+        if !matched && (cfg.Silent || cfg.StdoutMatchesOnly) {
+                return infoOut
+        }
+        return os.Stdout
+}
+
+// jsonLine formats a result as a single JSON object for -output-form json
+func jsonLine(result Result) string {
+        line, err := json.Marshal(newJSONResult(result))
+        if err != nil {
+                return "{}"
+        }
+        return string(line)
+}
+
+// grepLine formats a result as "host:port status category" for -output-form grep.
+// The category is the failure category for errors, otherwise match or nomatch.
+// A tag from -input-format csv is appended as a fourth field.
+func grepLine(result Result) string {
+        hostPort, err := targetHostPort(result.Domain)
+        if err != nil {
+                hostPort = result.Domain
+        }
+
+        category := result.Category
+        switch {
+        case result.Error != nil && category == "":
+                category = CategoryNetwork
+        case result.Error == nil && result.Matched:
+                category = "match"
+        case result.Error == nil:
+                category = "nomatch"
+        }
+        line := fmt.Sprintf("%s %03d %s", hostPort, result.StatusCode, category)
+        if result.Tag != "" {
+                line += " " + result.Tag
+        }
+        return line
+}
+
+// jsonResult is the JSON form of a Result, shared by -output-form json,
+// the -serve web view and -compare
+type jsonResult struct {
+        Domain     string  `json:"domain"`
+        StatusCode int     `json:"status_code"`
+        Status     string  `json:"status"`
+        Matched    bool    `json:"matched"`
+        Category   string  `json:"category,omitempty"`
+        Error      string  `json:"error,omitempty"`
+        ResolvedIP string  `json:"resolved_ip"`
+        TLSError   string  `json:"tls_error,omitempty"`
+        Duration   float64 `json:"duration"`
+        Tag        string  `json:"tag,omitempty"`
+        WWWVariant bool    `json:"www_variant,omitempty"`
+}
+
+// newJSONResult converts a result to its JSON form
+func newJSONResult(result Result) jsonResult {
+        jr := jsonResult{
+                Domain:     result.Domain,
+                StatusCode: result.StatusCode,
+                Status:     http.StatusText(result.StatusCode),
+                Matched:    result.Matched,
+                Category:   result.Category,
+                ResolvedIP: result.ResolvedIP,
+                TLSError:   result.TLSError,
+                Duration:   result.Duration.Seconds(),
+                Tag:        result.Tag,
+                WWWVariant: result.WWWVariant,
+        }
+        switch {
+        case result.OutOfScope:
+                jr.Status = "Skipped"
+        case result.Error != nil:
+                jr.Status = "Failed"
+                jr.Error = result.Error.Error()
+        }
+        return jr
+}
+
+// outputLocks serializes writers that resolve to the same output file
+var outputLocks sync.Map
+
+// lockOutput locks the file at path for exclusive writing and returns the unlock func
+func lockOutput(path string) func() {
+        if abs, err := filepath.Abs(path); err == nil {
+                path = abs
+        }
+        mu, _ := outputLocks.LoadOrStore(path, &sync.Mutex{})
+        mu.(*sync.Mutex).Lock()
+        return mu.(*sync.Mutex).Unlock
+}
+
+// writeDomains saves the domains to path, one per line. In append mode the
+// file is extended instead of truncated, with a timestamp line marking the run.
+func writeDomains(path string, domains []string, appendMode bool) error {
+        defer lockOutput(path)()
+
+        flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+        if appendMode {
+                flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+        }
+        file, err := os.OpenFile(path, flags, 0644)
+        if err != nil {
+                return err
+        }
+        defer file.Close()
+
+        w := bufio.NewWriter(file)
+        if appendMode {
+                w.WriteString("# HostHunter run " + time.Now().Format(time.RFC3339) + "\n")
+        }
+        for _, domain := range domains {
+                w.WriteString(domain + "\n")
+        }
+        return w.Flush()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+        "context"
+        "errors"
+        "fmt"
+        "net"
+        "net/url"
+        "strings"
+        "syscall"
+
+        "golang.org/x/net/idna"
+)
+
+// CategoryOutOfScope marks targets skipped by -scope without sending a request
+const CategoryOutOfScope = "out-of-scope"
+
+// errOutOfScope is returned by the dialer for an address outside -scope
+var errOutOfScope = errors.New("address outside -scope")
+
+// scope holds the domain suffixes and networks that -scope allows
+type scope struct {
+        suffixes  []string
+        networks  []*net.IPNet
+        checkDial bool // networks are enforced on the address each connection goes to
+}
+
+// parseScope builds a scope from -scope values, each a domain suffix such as
+// example.com (which also covers its subdomains) or a CIDR such as 10.0.0.0/8
+func parseScope(values []string) (*scope, error) {
+        s := &scope{}
+        for _, value := range values {
+                for _, entry := range strings.Split(value, ",") {
+                        entry = strings.TrimSpace(entry)
+                        if entry == "" {
+                                continue
+                        }
+                        if strings.Contains(entry, "/") {
+                                _, network, err := net.ParseCIDR(entry)
+                                if err != nil {
+                                        return nil, fmt.Errorf("invalid -scope CIDR %q", entry)
+                                }
+                                s.networks = append(s.networks, network)
+                                continue
+                        }
+                        if ip := net.ParseIP(entry); ip != nil {
+                                bits := 8 * len(ip.To16())
+                                if ip.To4() != nil {
+                                        ip, bits = ip.To4(), 32
+                                }
+                                s.networks = append(s.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+                                continue
+                        }
+                        suffix, err := idna.Lookup.ToASCII(strings.TrimPrefix(strings.TrimPrefix(entry, "*"), "."))
+                        if err != nil || suffix == "" {
+                                return nil, fmt.Errorf("invalid -scope domain %q", entry)
+                        }
+                        s.suffixes = append(s.suffixes, strings.ToLower(suffix))
+                }
+        }
+        if len(s.suffixes) == 0 && len(s.networks) == 0 {
+                return nil, fmt.Errorf("-scope has no domains or CIDRs")
+        }
+        return s, nil
+}
+
+// allows reports whether the host is in scope. IP addresses must fall in a
+// scoped network; hostnames must match a suffix or, when networks are scoped,
+// resolve only to addresses inside them. With checkDial the dialer checks
+// the addresses it actually connects to, so hostnames are not looked up here.
+func (s *scope) allows(ctx context.Context, host string) bool {
+        host = strings.ToLower(strings.TrimSuffix(host, "."))
+        if ip := net.ParseIP(host); ip != nil {
+                return s.containsIP(ip)
+        }
+        if s.matchesSuffix(host) {
+                return true
+        }
+        if len(s.networks) == 0 {
+                return false
+        }
+        if s.checkDial {
+                return true
+        }
+
+        addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+        if err != nil || len(addrs) == 0 {
+                return false
+        }
+        for _, addr := range addrs {
+                if !s.containsIP(addr.IP) {
+                        return false
+                }
+        }
+        return true
+}
+
+// matchesSuffix reports whether the host is one of the scoped domains or a subdomain of one
+func (s *scope) matchesSuffix(host string) bool {
+        host = strings.ToLower(strings.TrimSuffix(host, "."))
+        for _, suffix := range s.suffixes {
+                if host == suffix || strings.HasSuffix(host, "."+suffix) {
+                        return true
+                }
+        }
+        return false
+}
+
+// allowsURL reports whether the host of a request URL is in scope
+func (s *scope) allowsURL(ctx context.Context, u *url.URL) bool {
+        return s.allows(ctx, u.Hostname())
+}
+
+// containsIP reports whether ip falls in any scoped network
+func (s *scope) containsIP(ip net.IP) bool {
+        for _, network := range s.networks {
+                if network.Contains(ip) {
+                        return true
+                }
+        }
+        return false
+}
+
+// outOfScopeResult is the result for a target skipped by -scope
+func outOfScopeResult(domain string) Result {
+        return Result{
+                Domain:     domain,
+                Category:   CategoryOutOfScope,
+                OutOfScope: true,
+        }
+}
+
+// scopeCheckKey marks a dial whose addresses must fall in a scoped network
+type scopeCheckKey struct{}
+
+// scopedDialer enforces the scoped networks on the addresses actually dialed.
+// A separate lookup could get a different answer than the transport's own,
+// as with DNS rebinding, so only hosts under a scoped domain skip the check.
+type scopedDialer struct {
+        scope  *scope
+        dialer contextDialer
+}
+
+// DialContext marks hosts not covered by a scoped domain for dialControl
+func (d *scopedDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+        host, _, err := net.SplitHostPort(address)
+        if err != nil || !d.scope.matchesSuffix(host) {
+                ctx = context.WithValue(ctx, scopeCheckKey{}, true)
+        }
+        return d.dialer.DialContext(ctx, network, address)
+}
+
+// dialControl refuses a marked connection before it is made when the
+// resolved address is outside every scoped network
+func (s *scope) dialControl(ctx context.Context, network, address string, _ syscall.RawConn) error {
+        if ctx.Value(scopeCheckKey{}) == nil {
+                return nil
+        }
+        host, _, err := net.SplitHostPort(address)
+        if err != nil {
+                return err
+        }
+        if ip := net.ParseIP(host); ip == nil || !s.containsIP(ip) {
+                return fmt.Errorf("%w: %s", errOutOfScope, host)
+        }
+        return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+        "context"
+        "net/http"
+        "net/http/httptest"
+        "strings"
+        "testing"
+        "time"
+)
+
+func TestScopeChecksDialedAddress(t *testing.T) {
+        server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+        defer server.Close()
+        // A hostname passes the scope check before the request, so only the dialer can stop it
+        target := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+        tests := []struct {
+                name       string
+                scope      string
+                tcpCheck   bool
+                outOfScope bool
+        }{
+                {"dialed address in scope", "127.0.0.0/8", false, false},
+                {"dialed address outside scope", "10.0.0.0/8", false, true},
+                {"tcp check outside scope", "10.0.0.0/8", true, true},
+        }
+        for _, tt := range tests {
+                t.Run(tt.name, func(t *testing.T) {
+                        cfg := &Config{Scope: stringList{tt.scope}, TCPCheck: tt.tcpCheck, Timeout: time.Second, MaxBody: 1024}
+                        s, err := parseScope(cfg.Scope)
+                        if err != nil {
+                                t.Fatal(err)
+                        }
+                        s.checkDial = true
+                        cfg.scope = s
+
+                        result := NewStatusChecker(1, cfg).checkDomain(context.Background(), target)
+                        if result.OutOfScope != tt.outOfScope {
+                                t.Errorf("checkDomain(%s) with -scope %s: out of scope %v, want %v (error %v)",
+                                        target, tt.scope, result.OutOfScope, tt.outOfScope, result.Error)
+                        }
+                        if !tt.outOfScope && result.StatusCode != http.StatusOK {
+                                t.Errorf("checkDomain(%s) = %d, %v; want 200", target, result.StatusCode, result.Error)
+                        }
+                })
+        }
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+        "context"
+        "fmt"
+        "net/http"
+        "net/http/httptest"
+        "strconv"
+        "time"
+)
+
+// runSelfTest measures the throughput the scanner itself can reach by running
+// the normal worker pipeline against an in-process server, so network latency
+// does not factor in
+func runSelfTest(cfg *Config, numWorkers int) {
+        server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(http.StatusOK)
+        }))
+        defer server.Close()
+
+        domainsList := make([]string, cfg.SelfTestRequests)
+        for i := range domainsList {
+                domainsList[i] = server.URL + "/" + strconv.Itoa(i)
+        }
+
+        fmt.Printf("%sSelf-test: %d requests with %d workers against %s%s\n",
+                Magenta, len(domainsList), numWorkers, server.URL, Reset)
+
+        checker := NewStatusChecker(len(domainsList), cfg)
+        checker.quiet = true
+        start := time.Now()
+        checker.scan(context.Background(), domainsList, nil, numWorkers)
+        duration := time.Since(start)
+
+        fmt.Printf("\n%s----● Self-Test Results ●----%s\n", Magenta, Reset)
+        fmt.Printf("Requests completed: %d\n", checker.processedDomains)
+        fmt.Printf("Failed requests: %d\n", len(checker.failedDomains))
+        fmt.Printf("Total time taken: %.2fs\n", duration.Seconds())
+        fmt.Printf("Throughput: %.0f requests/s\n", float64(checker.processedDomains)/duration.Seconds())
+        checker.printWorkerStats()
+}
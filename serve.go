@@ -0,0 +1,106 @@
+package main
+
+import (
+        "context"
+        "encoding/json"
+        "html/template"
+        "net"
+        "net/http"
+        "sync"
+        "time"
+)
+
+// ResultStore keeps a thread-safe copy of the results for the web view
+type ResultStore struct {
+        mu      sync.RWMutex
+        results []jsonResult
+}
+
+// Add records a result in the store
+func (rs *ResultStore) Add(result Result) {
+        jr := newJSONResult(result)
+
+        rs.mu.Lock()
+        rs.results = append(rs.results, jr)
+        rs.mu.Unlock()
+}
+
+// Snapshot returns a copy of the results collected so far. It is never nil,
+// so /results.json is an empty array rather than null before the first result.
+func (rs *ResultStore) Snapshot() []jsonResult {
+        rs.mu.RLock()
+        defer rs.mu.RUnlock()
+        return append([]jsonResult{}, rs.results...)
+}
+
+// resultsPage renders the results as an auto-refreshing HTML table
+var resultsPage = template.Must(template.New("results").Funcs(template.FuncMap{
+        "statusClass": statusClass,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="2">
+<title>HostHunter Results</title>
+<style>
+body { font-family: monospace; background: #111; color: #ddd; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 12px; text-align: left; }
+.ok { color: #4c4; } .redirect { color: #4cc; } .client { color: #cc4; } .server { color: #c44; } .failed { color: #888; }
+</style>
+</head>
+<body>
+<h2>HostHunter Results ({{len .}})</h2>
+<table>
+<tr><th>Domain</th><th>Status</th><th>IP</th><th>Time</th><th>Tag</th></tr>
+{{range .}}<tr class="{{statusClass .}}"><td>{{.Domain}}</td><td>{{if .Error}}000 Failed{{else}}{{.StatusCode}} {{.Status}}{{end}}</td><td>{{.ResolvedIP}}</td><td>{{printf "%.2fs" .Duration}}</td><td>{{.Tag}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// statusClass maps a result to the CSS class used for its row
+func statusClass(sr jsonResult) string {
+        switch {
+        case sr.Error != "":
+                return "failed"
+        case sr.StatusCode >= 500:
+                return "server"
+        case sr.StatusCode >= 400:
+                return "client"
+        case sr.StatusCode >= 300:
+                return "redirect"
+        default:
+                return "ok"
+        }
+}
+
+// startServer serves the store on addr until the returned server is shut down
+func startServer(addr string, store *ResultStore) (*http.Server, error) {
+        mux := http.NewServeMux()
+        mux.HandleFunc("/results.json", func(w http.ResponseWriter, r *http.Request) {
+                w.Header().Set("Content-Type", "application/json")
+                json.NewEncoder(w).Encode(store.Snapshot())
+        })
+        mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+                w.Header().Set("Content-Type", "text/html; charset=utf-8")
+                resultsPage.Execute(w, store.Snapshot())
+        })
+
+        // Bind up front so an address in use is reported before the scan starts
+        listener, err := net.Listen("tcp", addr)
+        if err != nil {
+                return nil, err
+        }
+
+        srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+        go srv.Serve(listener)
+        return srv, nil
+}
+
+// stopServer gracefully shuts the server down, waiting briefly for open requests
+func stopServer(srv *http.Server) {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        srv.Shutdown(ctx)
+}
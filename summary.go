@@ -0,0 +1,160 @@
+package main
+
+import (
+        "fmt"
+        "os"
+        "strings"
+        "text/template"
+        "time"
+)
+
+// defaultSummaryFormat renders the summary shown when -summary-format is not set
+const defaultSummaryFormat = `{{if .Interrupted}}Scan interrupted after {{.Scanned}} of {{.Total}} domains
+{{if .AbortReason}}Abort reason: {{.AbortReason}}
+{{end}}Unscanned domains: {{.Unscanned}}
+{{end}}Total domains checked: {{.Scanned}}
+Successful domains: {{.Successful}}
+Failed domains: {{.Failed}} ({{.NetworkFailures}} network errors, {{.Unmatched}} unmatched responses)
+{{if .WWWVariants}}WWW variants added: {{.WWWVariants}} ({{.WWWMatches}} matched)
+{{end}}{{if .CmdMatches}}Matched by -match-cmd: {{.CmdMatches}}
+{{end}}{{if .OutOfScope}}Skipped as out of scope: {{.OutOfScope}}
+{{end}}{{if .SecondPass}}Recovered in second pass: {{.Recovered}}
+{{end}}{{if .RetryBudget}}Retries used: {{.RetriesUsed}} of {{.RetryBudget}}
+{{else if .RetriesUsed}}Retries used: {{.RetriesUsed}}
+{{end}}Total time taken: {{printf "%.2f" .Duration.Seconds}}s
+{{if .Scanned}}Average time per domain: {{printf "%.2f" .AverageTime}}s
+{{end}}{{if .Successful}}Response time (successful): p50 {{printf "%.2f" .P50.Seconds}}s, p90 {{printf "%.2f" .P90.Seconds}}s, p99 {{printf "%.2f" .P99.Seconds}}s, max {{printf "%.2f" .MaxTime.Seconds}}s
+{{end}}`
+
+// ScanSummary holds the totals reported at the end of a scan
+type ScanSummary struct {
+        Name            string
+        Total           int
+        Scanned         int
+        Successful      int
+        Failed          int
+        NetworkFailures int // never got an HTTP response
+        Unmatched       int // responded but did not match
+        Recovered       int
+        OutOfScope      int // skipped by -scope without a request
+        CmdMatches      int // successful results the -match-cmd command matched
+        WWWVariants     int // extra targets added by -www
+        WWWMatches      int
+        SecondPass      bool
+        RetriesUsed     int
+        RetryBudget     int
+        StatusCounts    map[int]int
+        Duration        time.Duration
+        Interrupted     bool
+        AbortReason     string
+        latency         latencyRecorder // durations of successful responses
+}
+
+// Unscanned returns the number of domains never checked because the scan stopped early
+func (s ScanSummary) Unscanned() int {
+        return s.Total - s.Scanned
+}
+
+// AverageTime returns the wall-clock seconds spent per domain actually checked
+func (s ScanSummary) AverageTime() float64 {
+        if s.Scanned == 0 {
+                return 0
+        }
+        return s.Duration.Seconds() / float64(s.Scanned)
+}
+
+// P50 returns the median response time of successful domains
+func (s ScanSummary) P50() time.Duration { return s.quantile(0.50) }
+
+// P90 returns the 90th percentile response time of successful domains
+func (s ScanSummary) P90() time.Duration { return s.quantile(0.90) }
+
+// P99 returns the 99th percentile response time of successful domains
+func (s ScanSummary) P99() time.Duration { return s.quantile(0.99) }
+
+// MaxTime returns the slowest successful response time
+func (s ScanSummary) MaxTime() time.Duration {
+        if s.latency == nil {
+                return 0
+        }
+        return s.latency.max()
+}
+
+func (s ScanSummary) quantile(q float64) time.Duration {
+        if s.latency == nil {
+                return 0
+        }
+        return s.latency.quantile(q)
+}
+
+// Throughput returns the domains checked per second
+func (s ScanSummary) Throughput() float64 {
+        if s.Duration <= 0 {
+                return 0
+        }
+        return float64(s.Scanned) / s.Duration.Seconds()
+}
+
+// add accumulates another summary into s for the aggregate report
+func (s *ScanSummary) add(other ScanSummary) {
+        s.Total += other.Total
+        s.Scanned += other.Scanned
+        s.Successful += other.Successful
+        s.Failed += other.Failed
+        s.NetworkFailures += other.NetworkFailures
+        s.Unmatched += other.Unmatched
+        s.Recovered += other.Recovered
+        s.OutOfScope += other.OutOfScope
+        s.CmdMatches += other.CmdMatches
+        s.WWWVariants += other.WWWVariants
+        s.WWWMatches += other.WWWMatches
+        s.RetriesUsed += other.RetriesUsed
+        s.RetryBudget = max(s.RetryBudget, other.RetryBudget)
+        s.SecondPass = s.SecondPass || other.SecondPass
+        s.Interrupted = s.Interrupted || other.Interrupted
+        if s.AbortReason == "" {
+                s.AbortReason = other.AbortReason
+        }
+        if s.StatusCounts == nil {
+                s.StatusCounts = make(map[int]int)
+        }
+        if s.latency != nil && other.latency != nil {
+                s.latency.merge(other.latency)
+        }
+        for code, n := range other.StatusCounts {
+                s.StatusCounts[code] += n
+        }
+}
+
+// print renders the summary with the configured template. The titled header
+// is only shown for the built-in format so custom output stays machine-readable.
+func (s ScanSummary) print(cfg *Config, title string) {
+        if cfg.SummaryFormat == "" {
+                fmt.Fprintf(infoOut, "\n%s----● %s ●----%s\n", Magenta, title, Reset)
+        }
+
+        var sb strings.Builder
+        if err := cfg.summaryTemplate.Execute(&sb, s); err != nil {
+                fmt.Fprintf(os.Stderr, "%sError: Unable to render summary - %v%s\n", Magenta, err, Reset)
+                return
+        }
+        out := sb.String()
+        if !strings.HasSuffix(out, "\n") {
+                out += "\n"
+        }
+        fmt.Fprint(infoOut, out)
+}
+
+// parseSummaryTemplate compiles a summary template and test-renders it so
+// mistakes such as unknown fields are reported before the scan starts
+func parseSummaryTemplate(format string) (*template.Template, error) {
+        tmpl, err := template.New("summary").Parse(format)
+        if err != nil {
+                return nil, err
+        }
+        sample := ScanSummary{StatusCounts: map[int]int{}}
+        if err := tmpl.Execute(&strings.Builder{}, sample); err != nil {
+                return nil, err
+        }
+        return tmpl, nil
+}
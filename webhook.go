@@ -0,0 +1,84 @@
+package main
+
+import (
+        "bytes"
+        "context"
+        "crypto/tls"
+        "encoding/json"
+        "fmt"
+        "net/http"
+        "os"
+        "time"
+)
+
+// webhookTimeout bounds how long delivering the summary may take
+const webhookTimeout = 10 * time.Second
+
+// webhookPayload is the JSON body posted to -webhook when a scan completes
+type webhookPayload struct {
+        Total           int         `json:"total"`
+        Scanned         int         `json:"scanned"`
+        Successful      int         `json:"successful"`
+        Failed          int         `json:"failed"`
+        NetworkFailures int         `json:"network_failures"`
+        Unmatched       int         `json:"unmatched"`
+        OutOfScope      int         `json:"out_of_scope"`
+        StatusCounts    map[int]int `json:"status_counts"`
+        Duration        float64     `json:"duration_seconds"`
+        Interrupted     bool        `json:"interrupted"`
+        AbortReason     string      `json:"abort_reason,omitempty"`
+}
+
+// notifyWebhook posts the summary to url using the scan's transport settings.
+// Delivery problems are reported on stderr but never fail the scan.
+func notifyWebhook(cfg *Config, url string, summary ScanSummary) {
+        payload, err := json.Marshal(webhookPayload{
+                Total:           summary.Total,
+                Scanned:         summary.Scanned,
+                Successful:      summary.Successful,
+                Failed:          summary.Failed,
+                NetworkFailures: summary.NetworkFailures,
+                Unmatched:       summary.Unmatched,
+                OutOfScope:      summary.OutOfScope,
+                StatusCounts:    summary.StatusCounts,
+                Duration:        summary.Duration.Seconds(),
+                Interrupted:     summary.Interrupted,
+                AbortReason:     summary.AbortReason,
+        })
+        if err != nil {
+                fmt.Fprintf(os.Stderr, "%sError: Unable to encode webhook payload - %v%s\n", Magenta, err, Reset)
+                return
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+        defer cancel()
+        req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+        if err != nil {
+                fmt.Fprintf(os.Stderr, "%sError: Unable to send webhook - %v%s\n", Magenta, err, Reset)
+                return
+        }
+        req.Header.Set("Content-Type", "application/json")
+
+        resp, err := webhookClient(cfg).Do(req)
+        if err != nil {
+                fmt.Fprintf(os.Stderr, "%sError: Unable to send webhook - %v%s\n", Magenta, err, Reset)
+                return
+        }
+        resp.Body.Close()
+        if resp.StatusCode < 200 || resp.StatusCode > 299 {
+                fmt.Fprintf(os.Stderr, "%sError: Webhook returned %s%s\n", Magenta, resp.Status, Reset)
+        }
+}
+
+// webhookClient returns a client for delivering the summary. It honours
+// -proxy but, unlike the scanner, always verifies certificates and ignores
+// scan-only settings such as -scope and -local-addr.
+func webhookClient(cfg *Config) *http.Client {
+        transport := &http.Transport{
+                TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+        }
+        if cfg.proxyURL != nil {
+                transport.Proxy = http.ProxyURL(cfg.proxyURL)
+        }
+        return &http.Client{Transport: transport, Timeout: webhookTimeout}
+}
@@ -2,31 +2,69 @@ package main
 
 import (
         "bufio"
+        "bytes"
+        "context"
         "crypto/tls"
+        "crypto/x509"
+        "errors"
+        "flag"
         "fmt"
+        "io"
+        "math/rand"
         "net"
         "net/http"
+        "net/http/httptrace"
+        "net/url"
         "os"
+        "os/signal"
+        "slices"
+        "sort"
         "strconv"
         "strings"
         "sync"
+        "sync/atomic"
         "time"
 )
 
 // ANSI color codes
-const (
+var (
         Gray    = "\033[90m"
         Green   = "\033[32m"
         green    = "\033[34m"
+        Cyan    = "\033[36m"
+        Yellow  = "\033[33m"
+        Red     = "\033[31m"
         Magenta = "\033[36m"
         Reset   = "\033[0m"
 )
 
+// disableColors blanks every color code so output is plain text
+func disableColors() {
+        Gray, Green, green, Cyan, Yellow, Red, Magenta, Reset = "", "", "", "", "", "", "", ""
+}
+
+// statusColor picks the color for a response by its status class
+func statusColor(statusCode int) string {
+        switch {
+        case statusCode >= 500:
+                return Red
+        case statusCode >= 400:
+                return Yellow
+        case statusCode >= 300:
+                return Cyan
+        default:
+                return Green
+        }
+}
+
 // Configuration
 const (
         bufferSize       = 100
         connectionTimeout = 3 * time.Second
         retryAttempts     = 0
+        failRateWindow    = 100
+        rampSteps         = 10
+        defaultWorkers    = 50 // used when the speed prompt gets no usable answer
 )
 
 // ASCII Art Banner
@@ -44,18 +82,68 @@ const banner = `
 |_| |_|\___/|___/\__| |_| |_|\__,_|_| |_|\__\___|_|   
 `
 
+// Target is a domain queued for checking along with its position in the input
+type Target struct {
+        Index  int
+        Domain string
+}
+
 // Result represents the outcome of checking a domain
 type Result struct {
-        Domain     string
-        StatusCode int
-        Error      error
-        Duration   time.Duration
+        Index         int
+        Input         string // the domain as given in the input, before any scheme is added
+        Domain        string
+        StatusCode    int
+        Error         error
+        Category      string
+        Duration      time.Duration
+        Matched       bool
+        MatchedBy     []string
+        ResolvedIP    string
+        TLSError      string
+        BodySize      int
+        BodyTruncated bool
+        ResetRetried  bool
+        DefaultPage   bool
+        OutOfScope    bool
+        Tag           string
+        WWWVariant    bool
+}
+
+// WorkerStats tracks how much work a single worker did
+type WorkerStats struct {
+        Domains int
+        Busy    time.Duration
 }
 
 // StatusChecker manages the domain checking process
 type StatusChecker struct {
         client            *http.Client
+        cfg               *Config
+        dialer            contextDialer
         successfulDomains []string
+        latency           latencyRecorder
+        failedDomains     []string
+        failedCodes       []int
+        statusCounts      map[int]int
+        retryCodes        []int
+        networkFailures   int
+        unmatched         int
+        outOfScope        int
+        cmdMatches        int
+        tags              map[string]string
+        variants          map[string]bool
+        matchedResults    []Result
+        limiter           *adaptiveLimiter
+        variantMatches    int
+        store             *ResultStore
+        abort             context.CancelCauseFunc
+        recentFailures    [failRateWindow]bool
+        recentCount       int
+        workerStats       []WorkerStats
+        quiet             bool
+        retryBudget       *retryBudget
+        retriesUsed       atomic.Int64
         mu                sync.Mutex
         startTime         time.Time
         totalDomains      int
@@ -63,38 +151,79 @@ type StatusChecker struct {
 }
 
 // NewStatusChecker initializes the checker with a high-performance HTTP client
-func NewStatusChecker(totalDomains int) *StatusChecker {
+func NewStatusChecker(totalDomains int, cfg *Config) *StatusChecker {
+        client, dialer := newHTTPClient(cfg)
+        return &StatusChecker{
+                client:       client,
+                cfg:          cfg,
+                dialer:       dialer,
+                statusCounts: make(map[int]int),
+                latency:      newLatencyRecorder(cfg),
+                startTime:    time.Now(),
+                totalDomains: totalDomains,
+        }
+}
+
+// newHTTPClient builds the client used for scanning from the transport flags,
+// along with the dialer it connects through
+func newHTTPClient(cfg *Config) (*http.Client, contextDialer) {
+        base := net.Dialer{
+                Timeout:   cfg.Timeout,
+                KeepAlive: 10 * time.Second,
+        }
+        // With CIDRs in -scope, check the address every connection goes to
+        scoped := cfg.scope != nil && cfg.scope.checkDial
+        if scoped {
+                base.ControlContext = cfg.scope.dialControl
+        }
+        var dialer contextDialer = &base
+        if len(cfg.localAddrs) > 0 {
+                dialer = newRotatingDialer(base, cfg.localAddrs)
+        }
+        if scoped {
+                dialer = &scopedDialer{scope: cfg.scope, dialer: dialer}
+        }
         transport := &http.Transport{
-                DialContext: (&net.Dialer{
-                        Timeout:   connectionTimeout,
-                        KeepAlive: 10 * time.Second,
-                }).DialContext,
-                TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
-                MaxIdleConns:          500,
-                MaxIdleConnsPerHost:   100,
-                IdleConnTimeout:       10 * time.Second,
+                DialContext:           dialer.DialContext,
+                TLSClientConfig:       &tls.Config{InsecureSkipVerify: !cfg.RequireValidTLS, MinVersion: cfg.minTLSVersion},
+                MaxIdleConns:          cfg.MaxIdleConns,
+                MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+                IdleConnTimeout:       cfg.IdleConnTimeout,
                 DisableKeepAlives:     false,
                 DisableCompression:    true,
         }
+        if cfg.proxyURL != nil {
+                transport.Proxy = http.ProxyURL(cfg.proxyURL)
+        }
 
-        return &StatusChecker{
-                client: &http.Client{
-                        Transport: transport,
-                        Timeout:   connectionTimeout,
-                },
-                startTime:    time.Now(),
-                totalDomains: totalDomains,
+        client := &http.Client{
+                Transport: transport,
+                Timeout:   cfg.Timeout,
+        }
+        // Stop at redirects that leave -scope, keeping the redirect response
+        if cfg.scope != nil {
+                client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+                        if len(via) >= 10 {
+                                return fmt.Errorf("stopped after 10 redirects")
+                        }
+                        if !cfg.scope.allowsURL(req.Context(), req.URL) {
+                                return http.ErrUseLastResponse
+                        }
+                        return nil
+                }
         }
+        return client, dialer
 }
 
 // checkDomain performs a fast HTTP GET request
-func (sc *StatusChecker) checkDomain(domain string) Result {
+func (sc *StatusChecker) checkDomain(ctx context.Context, domain string) Result {
         start := time.Now()
         if !strings.HasPrefix(domain, "http") {
                 domain = "https://" + domain
         }
 
-        resp, err := sc.client.Get(domain)
+        // Connect using the punycode form but keep the original for display
+        target, err := asciiURL(domain)
         if err != nil {
                 return Result{
                         Domain:   domain,
@@ -102,145 +231,957 @@ func (sc *StatusChecker) checkDomain(domain string) Result {
                         Duration: time.Since(start),
                 }
         }
+
+        // Never send anything to a host outside -scope
+        if sc.cfg.scope != nil {
+                if u, err := url.Parse(target); err != nil || !sc.cfg.scope.allowsURL(ctx, u) {
+                        return outOfScopeResult(domain)
+                }
+        }
+
+        // Skip the HTTP request entirely when nothing is listening on the port.
+        // Through a proxy the target may not be directly reachable, so don't check.
+        if sc.cfg.TCPCheck && sc.cfg.proxyURL == nil {
+                if err := sc.tcpCheck(ctx, target); err != nil {
+                        if errors.Is(err, errOutOfScope) {
+                                return outOfScopeResult(domain)
+                        }
+                        return Result{
+                                Domain:   domain,
+                                Error:    err,
+                                Category: CategoryTCP,
+                                Duration: time.Since(start),
+                        }
+                }
+        }
+
+        // Defeat CDN caches without changing the domain that gets recorded
+        if sc.cfg.CacheBust {
+                target = cacheBust(target)
+        }
+
+        trace, remoteIP := newIPTrace()
+        req, err := sc.newRequest(httptrace.WithClientTrace(ctx, trace), target)
+        if err != nil {
+                return Result{
+                        Domain:   domain,
+                        Error:    err,
+                        Duration: time.Since(start),
+                }
+        }
+
+        resp, resetRetried, err := sc.doWithRetries(ctx, req)
+        if errors.Is(err, errOutOfScope) {
+                return outOfScopeResult(domain)
+        }
+        if err != nil {
+                return Result{
+                        Domain:       domain,
+                        Error:        err,
+                        Category:     categorizeError(err),
+                        Duration:     time.Since(start),
+                        ResolvedIP:   remoteIP(),
+                        ResetRetried: resetRetried,
+                }
+        }
         defer resp.Body.Close()
 
-        // Record successful domains, removing "https://" from the domain
-        if resp.StatusCode >= 1 && resp.StatusCode <= 500 {
-                sc.mu.Lock()
-                domain = strings.TrimPrefix(domain, "https://") // Remove "https://" from the successful domain
-                sc.successfulDomains = append(sc.successfulDomains, domain)
-                sc.mu.Unlock()
+        // Flag certificates that would have failed verification
+        tlsError := ""
+        if sc.cfg.ReportInvalidTLS && resp.TLS != nil {
+                // After redirects the certificate belongs to the final host, not the original one
+                if err := verifyCertificates(resp.TLS, resp.Request.URL.Hostname()); err != nil {
+                        tlsError = err.Error()
+                }
+        }
+
+        // Body-consuming features share one capped read of the response
+        var body []byte
+        truncated := false
+        if sc.needsBody() {
+                body, truncated, err = sc.readBody(resp)
+                if err != nil {
+                        return Result{
+                                Domain:     domain,
+                                Error:      err,
+                                Category:   categorizeError(err),
+                                Duration:   time.Since(start),
+                                ResolvedIP: remoteIP(),
+                        }
+                }
+        }
+
+        matched, matchedBy := matchResponse(sc.cfg.matchers, sc.cfg.MatchLogic, resp, body)
+        defaultPage := sc.cfg.FilterDefault && isDefaultPage(body, sc.cfg.defaultSignatures)
+        matched = matched && !defaultPage
+
+        // Successful domains are recorded without the "https://" prefix
+        if matched {
+                domain = strings.TrimPrefix(domain, "https://")
         }
 
         return Result{
-                Domain:     domain,
-                StatusCode: resp.StatusCode,
-                Duration:   time.Since(start),
+                Domain:        domain,
+                StatusCode:    resp.StatusCode,
+                Duration:      time.Since(start),
+                Matched:       matched,
+                MatchedBy:     matchedBy,
+                ResolvedIP:    remoteIP(),
+                TLSError:      tlsError,
+                BodySize:      len(body),
+                BodyTruncated: truncated,
+                ResetRetried:  resetRetried,
+                DefaultPage:   defaultPage,
         }
 }
 
-// worker processes domains from the channel
-func (sc *StatusChecker) worker(domains <-chan string, results chan<- Result, wg *sync.WaitGroup) {
-        defer wg.Done()
-        for domain := range domains {
-                results <- sc.checkDomain(domain)
+// newRequest builds the request for target using -method, -body and -content-type.
+// A bytes.Reader body sets GetBody, so the body can be replayed on retries.
+func (sc *StatusChecker) newRequest(ctx context.Context, target string) (*http.Request, error) {
+        var body io.Reader
+        if sc.cfg.requestBody != nil {
+                body = bytes.NewReader(sc.cfg.requestBody)
+        }
+        req, err := http.NewRequestWithContext(ctx, sc.cfg.Method, target, body)
+        if err != nil {
+                return nil, err
+        }
+        if sc.cfg.ContentType != "" {
+                req.Header.Set("Content-Type", sc.cfg.ContentType)
         }
+        return req, nil
 }
 
-// processResults formats and prints results in real-time
-func (sc *StatusChecker) processResults(results <-chan Result) {
-        for result := range results {
-                sc.mu.Lock()
-                sc.processedDomains++
-                percentage := float64(sc.processedDomains) / float64(sc.totalDomains) * 100
-                sc.mu.Unlock()
+// doWithRetries sends the request, retrying up to -retries times on errors.
+// A connection reset or broken pipe is almost always transient, so it gets
+// one extra retry on top of that; the flag reports whether it was used.
+func (sc *StatusChecker) doWithRetries(ctx context.Context, req *http.Request) (*http.Response, bool, error) {
+        resetRetried := false
+        retries := 0
+        for {
+                resp, err := sc.client.Do(req)
+                if err == nil || ctx.Err() != nil || errors.Is(err, errOutOfScope) {
+                        return resp, resetRetried, err
+                }
+                switch {
+                case isConnReset(err) && !resetRetried && sc.takeRetry():
+                        resetRetried = true
+                case retries < sc.cfg.Retries && sc.takeRetry():
+                        retries++
+                default:
+                        return nil, resetRetried, err
+                }
 
-                if result.Error != nil {
-                        fmt.Printf("%s%-50s 000 Failed (%.2fs) ---> %6.1f%%%s\n",
-                                Gray, result.Domain, result.Duration.Seconds(), percentage, Reset)
-                } else {
-                        fmt.Printf("%s%-50s %d %s (%.2fs) ---> %6.1f%%%s\n",
-                                Green, result.Domain, result.StatusCode, http.StatusText(result.StatusCode),
-                                result.Duration.Seconds(), percentage, Reset)
+                // The failed attempt may have consumed the body
+                if req.GetBody != nil {
+                        if req.Body, err = req.GetBody(); err != nil {
+                                return nil, resetRetried, err
+                        }
                 }
         }
 }
 
-// printGreenDomains prints only the successful domains in green
-func (sc *StatusChecker) printGreenDomains() {
-        fmt.Printf("\n%s----● Successful Domains ●----%s\n", Magenta, Reset)
-        for _, domain := range sc.successfulDomains {
-                fmt.Println(Green + domain + Reset)
+// retryBudget caps the number of retries across the whole scan
+type retryBudget struct {
+        limit int64 // 0 means unlimited
+        used  atomic.Int64
+}
+
+// take claims one retry, reporting false once the budget is exhausted
+func (b *retryBudget) take() bool {
+        for {
+                used := b.used.Load()
+                if b.limit > 0 && used >= b.limit {
+                        return false
+                }
+                if b.used.CompareAndSwap(used, used+1) {
+                        return true
+                }
         }
 }
 
-func main() {
-        if len(os.Args) < 2 {
-                fmt.Printf("%sUsage: %s <hostfile>%s\n", Green, os.Args[0], Reset)
-                os.Exit(1)
+// takeRetry claims a retry from the shared budget and counts it for this checker
+func (sc *StatusChecker) takeRetry() bool {
+        if sc.retryBudget != nil && !sc.retryBudget.take() {
+                return false
         }
+        sc.retriesUsed.Add(1)
+        return true
+}
 
-        // Open file and read domains
-        file, err := os.Open(os.Args[1])
+// needsBody reports whether any enabled feature inspects the response body
+func (sc *StatusChecker) needsBody() bool {
+        return sc.cfg.Verbose || sc.cfg.FilterDefault || len(sc.cfg.MatchBody) > 0 || sc.cfg.MatchCmd != ""
+}
+
+// readBody reads at most -max-body bytes of the response so a huge or endless
+// body cannot exhaust memory, reporting whether the body was cut short
+func (sc *StatusChecker) readBody(resp *http.Response) ([]byte, bool, error) {
+        limit := sc.cfg.MaxBody
+        body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
         if err != nil {
-                fmt.Printf("%sError: Unable to open file - %v%s\n", Magenta, err, Reset)
-                os.Exit(1)
+                return nil, false, err
+        }
+        if int64(len(body)) > limit {
+                return body[:limit], true, nil
+        }
+        return body, false, nil
+}
+
+// verifyCertificates validates the peer certificate chain the way a strict
+// client would, for connections made with verification disabled
+func verifyCertificates(cs *tls.ConnectionState, host string) error {
+        if len(cs.PeerCertificates) == 0 {
+                return fmt.Errorf("no peer certificates")
+        }
+        opts := x509.VerifyOptions{
+                DNSName:       host,
+                Intermediates: x509.NewCertPool(),
+        }
+        for _, cert := range cs.PeerCertificates[1:] {
+                opts.Intermediates.AddCert(cert)
+        }
+        _, err := cs.PeerCertificates[0].Verify(opts)
+        return err
+}
+
+// tcpCheck dials the target's port with the transport's dialer and closes the connection
+func (sc *StatusChecker) tcpCheck(ctx context.Context, target string) error {
+        hostPort, err := targetHostPort(target)
+        if err != nil {
+                return err
+        }
+        conn, err := sc.dialer.DialContext(ctx, "tcp", hostPort)
+        if err != nil {
+                return err
+        }
+        return conn.Close()
+}
+
+// cacheBust appends a random query parameter so caches cannot serve a stored response
+func cacheBust(target string) string {
+        fragment := ""
+        if i := strings.Index(target, "#"); i >= 0 {
+                target, fragment = target[:i], target[i:]
+        }
+        sep := "?"
+        if strings.Contains(target, "?") {
+                sep = "&"
         }
-        defer file.Close()
+        return target + sep + "_=" + strconv.FormatUint(rand.Uint64(), 36) + fragment
+}
 
-        var domainsList []string
-        scanner := bufio.NewScanner(file)
-        for scanner.Scan() {
-                domain := strings.TrimSpace(scanner.Text())
-                if domain != "" {
-                        domainsList = append(domainsList, domain)
+// newIPTrace returns a trace recording the IP a request connected to, or the
+// last address it attempted when the connection failed
+func newIPTrace() (*httptrace.ClientTrace, func() string) {
+        var mu sync.Mutex
+        var ip string
+        setAddr := func(addr string) {
+                if host, _, err := net.SplitHostPort(addr); err == nil {
+                        addr = host
                 }
+                mu.Lock()
+                ip = addr
+                mu.Unlock()
         }
 
-        totalDomains := len(domainsList)
-        if totalDomains == 0 {
-                fmt.Printf("%sNo domains found in the file.%s\n", Magenta, Reset)
-                os.Exit(1)
+        trace := &httptrace.ClientTrace{
+                ConnectStart: func(network, addr string) {
+                        setAddr(addr)
+                },
+                GotConn: func(info httptrace.GotConnInfo) {
+                        setAddr(info.Conn.RemoteAddr().String())
+                },
+        }
+        return trace, func() string {
+                mu.Lock()
+                defer mu.Unlock()
+                return ip
         }
+}
 
-        // Display the banner
-        fmt.Printf("\n%s%s%s\n", Magenta, banner, Reset)
+// isSuccessStatus reports whether a status code counts as a successful domain
+func isSuccessStatus(statusCode int) bool {
+        return statusCode >= 1 && statusCode <= 500
+}
 
-        // Ask user for desired speed
-        fmt.Print("Enter Scan Speed [example 50]: ")
-        var numWorkers int
-        for {
-                input := ""
-                fmt.Scanln(&input)
-                speed, err := strconv.Atoi(input)
-                if err != nil || speed <= 0 {
-                        fmt.Print("Invalid input. Enter a positive number: ")
+// worker processes domains from the channel
+func (sc *StatusChecker) worker(ctx context.Context, id int, domains <-chan Target, results chan<- Result, wg *sync.WaitGroup) {
+        defer wg.Done()
+        for target := range domains {
+                // Drain without checking once the scan has been cancelled
+                if ctx.Err() != nil {
                         continue
                 }
-                numWorkers = speed
-                break
+                // With -max-workers a worker needs a token before it may check
+                if sc.limiter != nil && !sc.limiter.acquire(ctx) {
+                        continue
+                }
+                result := sc.checkDomain(ctx, target.Domain)
+                if sc.limiter != nil {
+                        sc.limiter.release(result)
+                }
+                result.Index = target.Index
+                result.Input = target.Domain
+                result.Tag = sc.tags[target.Domain]
+                result.WWWVariant = sc.variants[target.Domain]
+
+                // Each worker only touches its own slot, so no locking is needed
+                if sc.workerStats != nil {
+                        sc.workerStats[id].Domains++
+                        sc.workerStats[id].Busy += result.Duration
+                }
+                if result.Error != nil && ctx.Err() != nil {
+                        continue // Aborted by cancellation, not a real failure
+                }
+                results <- result
+        }
+}
+
+// feedDomains sends domains to the workers until the list is exhausted or ctx is cancelled
+func feedDomains(ctx context.Context, domainsList []string, fuzz *fuzzTargets, domains chan<- Target) {
+        defer close(domains)
+        for i, domain := range domainsList {
+                select {
+                case <-ctx.Done():
+                        return
+                case domains <- Target{Index: i, Domain: domain}:
+                }
+        }
+        if fuzz != nil {
+                fuzz.feed(ctx, len(domainsList), domains)
+        }
+}
+
+// scan checks every domain in the list using numWorkers concurrent workers
+func (sc *StatusChecker) scan(ctx context.Context, domainsList []string, fuzz *fuzzTargets, numWorkers int) {
+        sc.totalDomains = len(domainsList)
+        if fuzz != nil {
+                sc.totalDomains += fuzz.total
         }
+        sc.processedDomains = 0
+        sc.failedDomains = nil
+        sc.failedCodes = nil
 
-        // Initialize checker
-        checker := NewStatusChecker(totalDomains)
-        domains := make(chan string, bufferSize)
+        domains := make(chan Target, bufferSize)
         results := make(chan Result, bufferSize)
 
-        // Start workers
-        var wg sync.WaitGroup
-        for i := 0; i < numWorkers; i++ {
-                wg.Add(1)
-                go checker.worker(domains, results, &wg)
+        // Keep stats from earlier passes, growing the slice when more workers run
+        if sc.cfg.WorkerStats && len(sc.workerStats) < numWorkers {
+                sc.workerStats = append(sc.workerStats, make([]WorkerStats, numWorkers-len(sc.workerStats))...)
+        }
+
+        // Adapt how many of the workers may run at once, starting from -workers if given
+        sc.limiter = nil
+        if sc.cfg.MaxWorkers > 0 {
+                initial := sc.cfg.Workers
+                if initial == 0 {
+                        initial = sc.cfg.MinWorkers
+                }
+                sc.limiter = newAdaptiveLimiter(min(sc.cfg.MinWorkers, numWorkers), numWorkers, initial)
+                done := make(chan struct{})
+                defer close(done)
+                go sc.limiter.run(ctx, done)
         }
 
         // Feed domains into the channel
+        fed := make(chan struct{})
         go func() {
-                for _, domain := range domainsList {
-                        domains <- domain
-                }
-                close(domains)
+                feedDomains(ctx, domainsList, fuzz, domains)
+                close(fed)
         }()
 
+        // Start workers, all at once or gradually with -ramp
+        var wg sync.WaitGroup
+        wg.Add(numWorkers)
+        if sc.cfg.Ramp > 0 && numWorkers > 1 {
+                go sc.rampWorkers(ctx, numWorkers, fed, domains, results, &wg)
+        } else {
+                for i := 0; i < numWorkers; i++ {
+                        go sc.worker(ctx, i, domains, results, &wg)
+                }
+        }
+
         // Start result processor
         go func() {
                 wg.Wait()
                 close(results)
         }()
 
+        // Report progress on stderr while result lines are hidden
+        if sc.statsEnabled() {
+                done := make(chan struct{})
+                defer close(done)
+                go sc.reportStats(done)
+        }
+
         // Display results
-        checker.processResults(results)
+        sc.processResults(results)
+
+        if sc.limiter != nil && sc.cfg.Verbose {
+                sc.limiter.printTimeline()
+        }
+}
+
+// statsEnabled reports whether periodic stats lines should be printed. They
+// are only needed when no progress lines are shown, and only on a terminal
+// unless forced with -stats.
+func (sc *StatusChecker) statsEnabled() bool {
+        if sc.quiet || sc.cfg.StatsInterval <= 0 {
+                return false
+        }
+        if sc.cfg.Stats {
+                return true
+        }
+        hidden := sc.cfg.Silent || sc.cfg.OutputForm != "text"
+        return hidden && isTerminal(os.Stderr)
+}
+
+// reportStats prints a stats line to stderr every -stats-interval until done is closed
+func (sc *StatusChecker) reportStats(done <-chan struct{}) {
+        ticker := time.NewTicker(sc.cfg.StatsInterval)
+        defer ticker.Stop()
+        start, last, lastProcessed := time.Now(), time.Now(), 0
+        for {
+                select {
+                case <-done:
+                        return
+                case <-ticker.C:
+                        sc.mu.Lock()
+                        processed, total, matches := sc.processedDomains, sc.totalDomains, len(sc.successfulDomains)
+                        sc.mu.Unlock()
+
+                        // Throughput covers the last interval only
+                        now := time.Now()
+                        rate := float64(processed-lastProcessed) / now.Sub(last).Seconds()
+                        last, lastProcessed = now, processed
+
+                        fmt.Fprintf(os.Stderr, "[stats] %d/%d (%.1f%%) | %.1f/s | matches: %d | elapsed: %.0fs\n",
+                                processed, total, float64(processed)/float64(total)*100, rate, matches, now.Sub(start).Seconds())
+                }
+        }
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+        info, err := f.Stat()
+        return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
 
-        // Summary
-        duration := time.Since(checker.startTime)
-        fmt.Printf("\n%s----● Summary ●----%s\n", Magenta, Reset)
-        fmt.Printf("Total domains checked: %d\n", totalDomains)
-        fmt.Printf("Successful domains: %d\n", len(checker.successfulDomains))
-        fmt.Printf("Failed domains: %d\n", totalDomains-len(checker.successfulDomains))
-        fmt.Printf("Total time taken: %.2fs\n", duration.Seconds())
-        if totalDomains > 0 {
-                fmt.Printf("Average time per domain: %.2fs\n", duration.Seconds()/float64(totalDomains))
+// rampWorkers starts the workers in rampSteps batches spread over -ramp so
+// the initial burst of connections is smoothed out. The remaining workers
+// are started at once when the scan is cancelled or no input is left, so
+// the scan never waits on the ramp schedule to finish.
+func (sc *StatusChecker) rampWorkers(ctx context.Context, numWorkers int, fed <-chan struct{}, domains <-chan Target, results chan<- Result, wg *sync.WaitGroup) {
+        steps := min(rampSteps, numWorkers)
+        // A -ramp shorter than the step count would round down to a zero interval
+        interval := max(sc.cfg.Ramp/time.Duration(steps), time.Nanosecond)
+        if sc.cfg.Verbose {
+                fmt.Fprintf(infoOut, "%sRamping up to %d workers in %d steps of ~%d every %s%s\n",
+                        Magenta, numWorkers, steps, numWorkers/steps, interval, Reset)
         }
 
-        // Print successful domains at the end
-        checker.printGreenDomains()
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        started := 0
+        for step := 1; ; step++ {
+                for target := numWorkers * min(step, steps) / steps; started < target; started++ {
+                        go sc.worker(ctx, started, domains, results, wg)
+                }
+                if started == numWorkers {
+                        return
+                }
+
+                select {
+                case <-ctx.Done():
+                        step = steps
+                case <-ticker.C:
+                        select {
+                        case <-fed:
+                                if len(domains) == 0 {
+                                        step = steps
+                                        continue
+                                }
+                        default:
+                        }
+                        if sc.cfg.Verbose {
+                                fmt.Fprintf(infoOut, "%sRamp: %d/%d workers active%s\n", Magenta, started, numWorkers, Reset)
+                        }
+                }
+        }
+}
+
+// processResults formats and prints results in real-time, or in input order with -ordered
+func (sc *StatusChecker) processResults(results <-chan Result) {
+        if !sc.cfg.Ordered {
+                for result := range results {
+                        sc.handleResult(result)
+                }
+                return
+        }
+
+        // Hold each result back until everything before it in the input is out
+        pending := make(map[int]Result)
+        next := 0
+        for result := range results {
+                pending[result.Index] = result
+                for r, ok := pending[next]; ok; r, ok = pending[next] {
+                        delete(pending, next)
+                        sc.handleResult(r)
+                        next++
+                }
+        }
+
+        // Cancellation can leave gaps, so flush whatever is left in order
+        indexes := make([]int, 0, len(pending))
+        for i := range pending {
+                indexes = append(indexes, i)
+        }
+        sort.Ints(indexes)
+        for _, i := range indexes {
+                sc.handleResult(pending[i])
+        }
+}
+
+// handleResult records a single result and prints it
+func (sc *StatusChecker) handleResult(result Result) {
+        sc.mu.Lock()
+        sc.processedDomains++
+        percentage := float64(sc.processedDomains) / float64(sc.totalDomains) * 100
+        if result.OutOfScope {
+                sc.outOfScope++
+                sc.mu.Unlock()
+                sc.printSkipped(result, percentage)
+                return
+        }
+        // A second-pass result replaces the first-pass failure it retries
+        if sc.retryCodes != nil {
+                sc.countResult(sc.retryCodes[result.Index], true, -1)
+        }
+        failed := !result.Matched
+        sc.countResult(result.StatusCode, failed, 1)
+        if result.Error != nil {
+                // Only request errors are worth a second pass, a 404 stays a 404.
+                // Retried from the input form so tags and -www variants still apply
+                sc.failedDomains = append(sc.failedDomains, result.Input)
+                sc.failedCodes = append(sc.failedCodes, result.StatusCode)
+        }
+        if !failed {
+                sc.successfulDomains = append(sc.successfulDomains, result.Domain)
+                sc.latency.record(result.Duration)
+                if slices.Contains(result.MatchedBy, cmdMatchDetail) {
+                        sc.cmdMatches++
+                }
+                if result.WWWVariant {
+                        sc.variantMatches++
+                }
+                // Only kept for -group, which needs statuses as well as domains
+                if sc.cfg.Group {
+                        sc.matchedResults = append(sc.matchedResults, result)
+                }
+        }
+        sc.mu.Unlock()
+
+        // Unmatched responses show the network is fine, so only errors count
+        sc.trackFailureRate(result.Error != nil)
+
+        if sc.store != nil {
+                sc.store.Add(result)
+        }
+
+        if sc.quiet {
+                return
+        }
+        switch {
+        case sc.cfg.OutputForm == "grep":
+                fmt.Fprintln(resultOut(sc.cfg, !failed), grepLine(result))
+        case sc.cfg.OutputForm == "json":
+                fmt.Fprintln(resultOut(sc.cfg, !failed), jsonLine(result))
+        default:
+                sc.printResult(result, percentage)
+                if (sc.cfg.StdoutMatchesOnly || sc.cfg.Silent) && !failed {
+                        fmt.Println(result.Domain)
+                }
+        }
+}
+
+// printSkipped notes a target that was skipped without being requested
+func (sc *StatusChecker) printSkipped(result Result, percentage float64) {
+        if sc.quiet {
+                return
+        }
+        switch sc.cfg.OutputForm {
+        case "grep":
+                fmt.Fprintln(resultOut(sc.cfg, false), grepLine(result))
+        case "json":
+                fmt.Fprintln(resultOut(sc.cfg, false), jsonLine(result))
+        default:
+                note := result.Category
+                if result.Tag != "" {
+                        note = "tag=" + result.Tag + ", " + note
+                }
+                fmt.Fprintf(infoOut, "%s%-50s --- Skipped [%s] ---> %6.1f%%%s\n",
+                        Gray, result.Domain, note, percentage, Reset)
+        }
+}
+
+// printResult prints one result line along with the scan progress
+func (sc *StatusChecker) printResult(result Result, percentage float64) {
+        var tags []string
+        if result.Tag != "" {
+                tags = append(tags, "tag="+result.Tag)
+        }
+        if result.Category != "" {
+                tags = append(tags, result.Category)
+        }
+        tags = append(tags, result.MatchedBy...)
+        if result.TLSError != "" {
+                tags = append(tags, "invalid-tls")
+        }
+        if result.DefaultPage {
+                tags = append(tags, "default-page")
+        }
+        if result.WWWVariant {
+                tags = append(tags, "www-variant")
+        }
+        if result.ResetRetried {
+                tags = append(tags, "reset-retry")
+        }
+        if sc.cfg.Verbose && result.ResolvedIP != "" {
+                tags = append(tags, result.ResolvedIP)
+        }
+        if sc.cfg.Verbose && result.Error == nil {
+                size := fmt.Sprintf("%dB", result.BodySize)
+                if result.BodyTruncated {
+                        size += "+"
+                }
+                tags = append(tags, size)
+        }
+        note := ""
+        if len(tags) > 0 {
+                note = " [" + strings.Join(tags, ", ") + "]"
+        }
+
+        if result.Error != nil {
+                fmt.Fprintf(infoOut, "%s%-50s 000 Failed%s (%.2fs) ---> %6.1f%%%s\n",
+                        Gray, result.Domain, note, result.Duration.Seconds(), percentage, Reset)
+        } else {
+                fmt.Fprintf(infoOut, "%s%-50s %d %s%s (%.2fs) ---> %6.1f%%%s\n",
+                        statusColor(result.StatusCode), result.Domain, result.StatusCode, http.StatusText(result.StatusCode),
+                        note, result.Duration.Seconds(), percentage, Reset)
+        }
+}
+
+// countResult adds delta to the status and failure counters for a result.
+// A status code of 0 means the request itself failed.
+func (sc *StatusChecker) countResult(statusCode int, failed bool, delta int) {
+        sc.statusCounts[statusCode] += delta
+        if sc.statusCounts[statusCode] == 0 {
+                delete(sc.statusCounts, statusCode)
+        }
+        switch {
+        case statusCode == 0:
+                sc.networkFailures += delta
+        case failed:
+                sc.unmatched += delta
+        }
+}
+
+// trackFailureRate aborts the scan once the rate of request errors over the
+// last failRateWindow results reaches the configured threshold. The second pass
+// only retries failures, so it is not tracked.
+func (sc *StatusChecker) trackFailureRate(failed bool) {
+        if sc.cfg.AbortFailRate <= 0 || sc.abort == nil || sc.retryCodes != nil {
+                return
+        }
+
+        sc.recentFailures[sc.recentCount%failRateWindow] = failed
+        sc.recentCount++
+        if sc.recentCount < failRateWindow {
+                return
+        }
+
+        failures := 0
+        for _, f := range sc.recentFailures {
+                if f {
+                        failures++
+                }
+        }
+        rate := float64(failures) / failRateWindow
+        if rate >= sc.cfg.AbortFailRate {
+                sc.abort(fmt.Errorf("%.0f%% of the last %d requests failed, likely a network problem", rate*100, failRateWindow))
+        }
+}
+
+// printWorkerStats prints how the domains were spread across the workers
+func (sc *StatusChecker) printWorkerStats() {
+        if len(sc.workerStats) == 0 {
+                return
+        }
+
+        fmt.Fprintf(infoOut, "\n%s----● Worker Stats ●----%s\n", Magenta, Reset)
+        fmt.Fprintf(infoOut, "%-8s %8s %10s %10s\n", "Worker", "Domains", "Busy", "Avg")
+        minDomains, maxDomains := sc.workerStats[0].Domains, sc.workerStats[0].Domains
+        for id, ws := range sc.workerStats {
+                avg := 0.0
+                if ws.Domains > 0 {
+                        avg = ws.Busy.Seconds() / float64(ws.Domains)
+                }
+                fmt.Fprintf(infoOut, "%-8d %8d %9.2fs %9.2fs\n", id, ws.Domains, ws.Busy.Seconds(), avg)
+                minDomains = min(minDomains, ws.Domains)
+                maxDomains = max(maxDomains, ws.Domains)
+        }
+        fmt.Fprintf(infoOut, "Domains per worker: min %d, max %d\n", minDomains, maxDomains)
+}
+
+// printGreenDomains prints only the successful domains in green
+func (sc *StatusChecker) printGreenDomains() {
+        fmt.Fprintf(infoOut, "\n%s----● Successful Domains ●----%s\n", Magenta, Reset)
+        for _, domain := range sc.successfulDomains {
+                fmt.Fprintln(infoOut, Green+domain+Reset)
+        }
+}
+
+// runScan checks a domain list, including the optional second pass, and summarizes it
+func (sc *StatusChecker) runScan(ctx context.Context, cfg *Config, in inputFile, numWorkers int) ScanSummary {
+        sc.tags = in.tags
+        sc.variants = in.variants
+        sc.scan(ctx, in.domains, in.fuzz, numWorkers)
+        summary := ScanSummary{
+                Total:      in.total(),
+                Scanned:    sc.processedDomains,
+                SecondPass: cfg.SecondPass,
+        }
+
+        // Give failed domains one more chance to rule out transient errors
+        if cfg.SecondPass && ctx.Err() == nil && len(sc.failedDomains) > 0 {
+                retryWorkers := numWorkers
+                if cfg.SecondPassWorkers > 0 && cfg.SecondPassWorkers < numWorkers {
+                        retryWorkers = cfg.SecondPassWorkers
+                }
+                fmt.Fprintf(infoOut, "\n%s----● Second Pass (%d domains) ●----%s\n", Magenta, len(sc.failedDomains), Reset)
+                before := len(sc.successfulDomains)
+                sc.retryCodes = sc.failedCodes
+                sc.scan(ctx, sc.failedDomains, nil, retryWorkers)
+                sc.retryCodes = nil
+                summary.Recovered = len(sc.successfulDomains) - before
+        }
+
+        summary.Successful = len(sc.successfulDomains)
+        summary.StatusCounts = sc.statusCounts
+        summary.latency = sc.latency
+        summary.RetriesUsed = int(sc.retriesUsed.Load())
+        summary.RetryBudget = cfg.RetryBudget
+        summary.NetworkFailures = sc.networkFailures
+        summary.Unmatched = sc.unmatched
+        summary.OutOfScope = sc.outOfScope
+        summary.CmdMatches = sc.cmdMatches
+        // -limit may have cut some variants off, so count the ones actually scanned
+        for _, domain := range in.domains {
+                if in.variants[domain] {
+                        summary.WWWVariants++
+                }
+        }
+        summary.WWWMatches = sc.variantMatches
+        summary.Failed = sc.networkFailures + sc.unmatched
+        summary.Duration = time.Since(sc.startTime)
+        summary.Interrupted = ctx.Err() != nil
+        if cause := context.Cause(ctx); cause != nil && cause != context.Canceled {
+                summary.AbortReason = cause.Error()
+        }
+        return summary
+}
+
+// workerCount returns the -workers value, asking the user when it is not set
+func workerCount(cfg *Config) int {
+        // Adaptive mode runs -max-workers goroutines and limits how many are active
+        if cfg.MaxWorkers > 0 {
+                return cfg.MaxWorkers
+        }
+        if cfg.Workers > 0 {
+                return cfg.Workers
+        }
+
+        return promptWorkers(os.Stdin, os.Stderr)
+}
+
+// promptWorkers asks for the scan speed one line at a time. An empty line
+// picks the default, and so does end of input, so a closed or piped stdin
+// can never leave the prompt spinning.
+func promptWorkers(in io.Reader, out io.Writer) int {
+        reader := bufio.NewReader(in)
+        fmt.Fprintf(out, "Enter Scan Speed [default %d]: ", defaultWorkers)
+        for {
+                line, err := reader.ReadString('\n')
+                input := strings.TrimSpace(line)
+                if input == "" {
+                        if err != nil {
+                                fmt.Fprintln(out)
+                        }
+                        return defaultWorkers
+                }
+                if speed, convErr := strconv.Atoi(input); convErr == nil && speed > 0 {
+                        return speed
+                }
+                if err != nil {
+                        fmt.Fprintf(out, "\nInvalid input %q, using %d\n", input, defaultWorkers)
+                        return defaultWorkers
+                }
+                fmt.Fprintf(out, "Invalid input %q. Enter a positive number: ", input)
+        }
+}
+
+// capWorkers limits numWorkers to the largest input, since workers beyond
+// the number of domains would only sit idle
+func capWorkers(numWorkers int, inputs []inputFile) int {
+        largest := 0
+        for _, in := range inputs {
+                largest = max(largest, in.total())
+        }
+        if numWorkers > largest {
+                fmt.Fprintf(infoOut, "%sNote: only %d domains to scan, reducing workers from %d to %d%s\n", Magenta, largest, numWorkers, largest, Reset)
+                return largest
+        }
+        return numWorkers
+}
+
+func main() {
+        cfg := parseFlags()
+        if cfg.NoColor {
+                disableColors()
+        }
+        configureOutput(cfg)
+        if cfg.SelfTest {
+                runSelfTest(cfg, workerCount(cfg))
+                return
+        }
+        if cfg.Compare {
+                if err := runCompare(flag.Arg(0), flag.Arg(1)); err != nil {
+                        fmt.Fprintf(os.Stderr, "%sError: Unable to compare results - %v%s\n", Magenta, err, Reset)
+                        os.Exit(1)
+                }
+                return
+        }
+        if flag.NArg() < 1 && len(cfg.Domains)+len(cfg.Bases) == 0 {
+                flag.Usage()
+                os.Exit(1)
+        }
+
+        inputs := loadInputs(cfg, flag.Args())
+        if len(inputs) == 0 {
+                os.Exit(1)
+        }
+        batch := len(inputs) > 1
+
+        // Display the banner on stderr so piped stdout stays clean
+        if !cfg.NoBanner {
+                fmt.Fprintf(os.Stderr, "\n%s%s%s\n", Magenta, banner, Reset)
+        }
+
+        numWorkers := workerCount(cfg)
+
+        numWorkers = capWorkers(numWorkers, inputs)
+
+        // Cancel the scan on Ctrl+C so partial results are still summarized
+        signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+        defer stop()
+        ctx, abort := context.WithCancelCause(signalCtx)
+        defer abort(nil)
+
+        // Enforce the overall wall-clock limit on top of per-request timeouts
+        if cfg.MaxDuration > 0 {
+                var cancel context.CancelFunc
+                ctx, cancel = context.WithTimeoutCause(ctx, cfg.MaxDuration,
+                        fmt.Errorf("time limit of %s reached", cfg.MaxDuration))
+                defer cancel()
+        }
+
+        // Optionally expose live results over HTTP
+        var store *ResultStore
+        var server *http.Server
+        if cfg.Serve != "" {
+                var err error
+                store = &ResultStore{}
+                server, err = startServer(cfg.Serve, store)
+                if err != nil {
+                        fmt.Fprintf(os.Stderr, "%sError: Unable to start server - %v%s\n", Magenta, err, Reset)
+                        os.Exit(1)
+                }
+                fmt.Fprintf(infoOut, "%sServing results on http://%s%s\n", Magenta, cfg.Serve, Reset)
+        }
+
+        // Retries are capped across all files, not per domain
+        budget := &retryBudget{limit: int64(cfg.RetryBudget)}
+
+        // Without a {file} placeholder every file shares one output, written at the end
+        perFileOutput := strings.Contains(cfg.Output, "{file}")
+        var allSuccessful []string
+
+        start := time.Now()
+        total := ScanSummary{latency: newLatencyRecorder(cfg)}
+        for _, in := range inputs {
+                // Files never started still count toward the total, as unscanned
+                if ctx.Err() != nil {
+                        total.Total += in.total()
+                        total.Interrupted = true
+                        if cause := context.Cause(ctx); total.AbortReason == "" && cause != context.Canceled {
+                                total.AbortReason = cause.Error()
+                        }
+                        continue
+                }
+                if batch {
+                        fmt.Fprintf(infoOut, "\n%s----● Scanning %s (%d domains) ●----%s\n", Magenta, in.path, in.total(), Reset)
+                }
+
+                // Initialize checker
+                checker := NewStatusChecker(in.total(), cfg)
+                checker.store = store
+                checker.retryBudget = budget
+                checker.abort = abort
+                summary := checker.runScan(ctx, cfg, in, numWorkers)
+
+                // Summary
+                title := "Summary"
+                if batch {
+                        title = "Summary: " + in.path
+                        summary.Name = in.path
+                }
+                summary.print(cfg, title)
+                checker.printWorkerStats()
+
+                // Print successful domains at the end, as a tree with -group
+                if cfg.Group {
+                        checker.printGroups()
+                } else {
+                        checker.printGreenDomains()
+                }
+
+                if perFileOutput {
+                        path := strings.ReplaceAll(cfg.Output, "{file}", in.path)
+                        if err := writeDomains(path, checker.successfulDomains, cfg.Append); err != nil {
+                                fmt.Fprintf(os.Stderr, "%sError: Unable to write output - %v%s\n", Magenta, err, Reset)
+                        }
+                }
+                allSuccessful = append(allSuccessful, checker.successfulDomains...)
+                total.add(summary)
+        }
+
+        total.Duration = time.Since(start)
+        if batch {
+                total.Name = "total"
+                total.print(cfg, fmt.Sprintf("Total (%d files)", len(inputs)))
+        }
+        if cfg.Output != "" && !perFileOutput {
+                if err := writeDomains(cfg.Output, allSuccessful, cfg.Append); err != nil {
+                        fmt.Fprintf(os.Stderr, "%sError: Unable to write output - %v%s\n", Magenta, err, Reset)
+                }
+        }
+
+        if cfg.Webhook != "" {
+                notifyWebhook(cfg, cfg.Webhook, total)
+        }
+
+        if server != nil {
+                stopServer(server)
+        }
 }
@@ -0,0 +1,264 @@
+package main
+
+import (
+        "context"
+        "errors"
+        "fmt"
+        "io"
+        "math/rand"
+        "reflect"
+        "strings"
+        "testing"
+        "time"
+)
+
+func TestFeedDomainsStopsOnCancel(t *testing.T) {
+        ctx, cancel := context.WithCancel(context.Background())
+        domains := make(chan Target, 1)
+        done := make(chan struct{})
+        go func() {
+                feedDomains(ctx, []string{"a.com", "b.com", "c.com", "d.com"}, nil, domains)
+                close(done)
+        }()
+
+        // Take one domain so the feeder is known to be running, then leave the channel full
+        <-domains
+        cancel()
+
+        select {
+        case <-done:
+        case <-time.After(time.Second):
+                t.Fatal("feedDomains did not return after cancellation")
+        }
+
+        // Whatever was buffered may still be read, then the channel must be closed
+        for {
+                select {
+                case _, ok := <-domains:
+                        if !ok {
+                                return
+                        }
+                case <-time.After(time.Second):
+                        t.Fatal("domains channel was not closed")
+                }
+        }
+}
+
+func TestStatusColor(t *testing.T) {
+        tests := []struct {
+                code int
+                want string
+        }{
+                {200, "\033[32m"},
+                {204, "\033[32m"},
+                {301, "\033[36m"},
+                {404, "\033[33m"},
+                {403, "\033[33m"},
+                {500, "\033[31m"},
+                {503, "\033[31m"},
+        }
+        for _, tt := range tests {
+                if got := statusColor(tt.code); got != tt.want {
+                        t.Errorf("statusColor(%d) = %q, want %q", tt.code, got, tt.want)
+                }
+        }
+}
+
+func TestStatusColorDisabled(t *testing.T) {
+        saved := []string{Gray, Green, green, Cyan, Yellow, Red, Magenta, Reset}
+        defer func() {
+                Gray, Green, green, Cyan, Yellow, Red, Magenta, Reset = saved[0], saved[1], saved[2], saved[3], saved[4], saved[5], saved[6], saved[7]
+        }()
+
+        disableColors()
+        for _, code := range []int{200, 301, 404, 500} {
+                if got := statusColor(code); got != "" {
+                        t.Errorf("statusColor(%d) = %q after disableColors, want no color", code, got)
+                }
+        }
+}
+
+// newTestChecker returns a checker that records results without printing them
+func newTestChecker(cfg *Config) *StatusChecker {
+        sc := NewStatusChecker(0, cfg)
+        sc.quiet = true
+        return sc
+}
+
+func TestProcessResultsOrdered(t *testing.T) {
+        // Index 4 never arrives, as when a scan is cancelled mid-way
+        indexes := []int{0, 1, 2, 3, 5, 6}
+        want := []string{"d0", "d1", "d2", "d3", "d5", "d6"}
+
+        for run := 0; run < 20; run++ {
+                sc := newTestChecker(&Config{Ordered: true})
+                sc.totalDomains = len(indexes)
+                results := make(chan Result, len(indexes))
+                arrival := make([]int, 0, len(indexes))
+                for _, p := range rand.Perm(len(indexes)) {
+                        i := indexes[p]
+                        arrival = append(arrival, i)
+                        results <- Result{Index: i, Domain: fmt.Sprintf("d%d", i), StatusCode: 200, Matched: true}
+                }
+                close(results)
+
+                sc.processResults(results)
+                if !reflect.DeepEqual(sc.successfulDomains, want) {
+                        t.Fatalf("arrival %v: emitted %q, want %q", arrival, sc.successfulDomains, want)
+                }
+        }
+}
+
+// feedResults runs results through the checker as one scan pass would
+func feedResults(sc *StatusChecker, results ...Result) {
+        sc.processedDomains = 0
+        sc.failedDomains = nil
+        sc.failedCodes = nil
+        sc.totalDomains = len(results)
+        ch := make(chan Result, len(results))
+        for _, r := range results {
+                ch <- r
+        }
+        close(ch)
+        sc.processResults(ch)
+}
+
+func TestCountsAddUpToScanned(t *testing.T) {
+        netErr := errors.New("connection refused")
+        sc := newTestChecker(&Config{})
+
+        feedResults(sc,
+                Result{Index: 0, Domain: "d0", StatusCode: 200, Matched: true},
+                Result{Index: 1, Input: "d1", Domain: "d1", Error: netErr},
+                Result{Index: 2, Domain: "d2", StatusCode: 404},
+                Result{Index: 3, Input: "d3", Domain: "d3", Error: netErr},
+                Result{Index: 4, Domain: "d4", StatusCode: 403},
+                Result{Index: 5, Domain: "d5", Category: CategoryOutOfScope, OutOfScope: true},
+        )
+        scanned := sc.processedDomains
+
+        // Second pass over the request errors d1 and d3, indexed by their position in failedDomains
+        if want := []string{"d1", "d3"}; !reflect.DeepEqual(sc.failedDomains, want) {
+                t.Fatalf("queued %q for the second pass, want %q", sc.failedDomains, want)
+        }
+        sc.retryCodes = sc.failedCodes
+        feedResults(sc,
+                Result{Index: 0, Domain: "d1", StatusCode: 200, Matched: true},
+                Result{Index: 1, Domain: "d3", Error: netErr},
+        )
+        sc.retryCodes = nil
+
+        successful := len(sc.successfulDomains)
+        if got := successful + sc.networkFailures + sc.unmatched + sc.outOfScope; got != scanned {
+                t.Errorf("successful %d + network %d + unmatched %d + out of scope %d = %d, want %d scanned",
+                        successful, sc.networkFailures, sc.unmatched, sc.outOfScope, got, scanned)
+        }
+        if successful != 2 || sc.networkFailures != 1 || sc.unmatched != 2 || sc.outOfScope != 1 {
+                t.Errorf("got successful %d, network %d, unmatched %d, out of scope %d; want 2, 1, 2, 1",
+                        successful, sc.networkFailures, sc.unmatched, sc.outOfScope)
+        }
+}
+
+func TestPromptWorkers(t *testing.T) {
+        tests := []struct {
+                name  string
+                input string
+                want  int
+        }{
+                {"empty stdin", "", defaultWorkers},
+                {"garbage then blank line", "abc\n\n", defaultWorkers},
+                {"garbage then EOF", "abc", defaultWorkers},
+                {"valid number", "7\n", 7},
+                {"garbage then valid number", "abc\n-3\n12\n", 12},
+        }
+        for _, tt := range tests {
+                t.Run(tt.name, func(t *testing.T) {
+                        if got := promptWorkers(strings.NewReader(tt.input), io.Discard); got != tt.want {
+                                t.Errorf("promptWorkers(%q) = %d, want %d", tt.input, got, tt.want)
+                        }
+                })
+        }
+}
+
+func TestCapWorkers(t *testing.T) {
+        saved := infoOut
+        infoOut = io.Discard
+        defer func() { infoOut = saved }()
+
+        three := []inputFile{{path: "hosts.txt", domains: []string{"a.com", "b.com", "c.com"}}}
+        if got := capWorkers(100, three); got != 3 {
+                t.Errorf("capWorkers(100, 3 domains) = %d, want 3", got)
+        }
+        if got := capWorkers(2, three); got != 2 {
+                t.Errorf("capWorkers(2, 3 domains) = %d, want 2", got)
+        }
+
+        // The largest input decides when several are scanned in turn
+        batch := append(three, inputFile{path: "more.txt", domains: []string{"d.com", "e.com", "f.com", "g.com", "h.com"}})
+        if got := capWorkers(100, batch); got != 5 {
+                t.Errorf("capWorkers(100, batch) = %d, want 5", got)
+        }
+}
+
+func TestFailureRateIgnoresSecondPass(t *testing.T) {
+        var cause error
+        sc := newTestChecker(&Config{AbortFailRate: 0.9})
+        sc.abort = func(err error) { cause = err }
+
+        failures := make([]Result, 2*failRateWindow)
+        for i := range failures {
+                failures[i] = Result{Index: i, Domain: fmt.Sprintf("d%d", i), Error: errors.New("connection refused")}
+        }
+        sc.retryCodes = make([]int, len(failures))
+        feedResults(sc, failures...)
+        sc.retryCodes = nil
+        if cause != nil {
+                t.Fatalf("second pass tripped the failure-rate breaker: %v", cause)
+        }
+
+        feedResults(sc, failures...)
+        if cause == nil {
+                t.Error("main pass at 100% network errors did not trip the breaker")
+        }
+}
+
+func TestFailureRateIgnoresUnmatched(t *testing.T) {
+        var cause error
+        sc := newTestChecker(&Config{AbortFailRate: 0.9})
+        sc.abort = func(err error) { cause = err }
+
+        unmatched := make([]Result, 2*failRateWindow)
+        for i := range unmatched {
+                unmatched[i] = Result{Index: i, Domain: fmt.Sprintf("d%d", i), StatusCode: 404}
+        }
+        feedResults(sc, unmatched...)
+        if cause != nil {
+                t.Errorf("unmatched responses tripped the failure-rate breaker: %v", cause)
+        }
+}
+
+func TestSecondPassKeepsTags(t *testing.T) {
+        const input = "http://127.0.0.1:1" // nothing listens on port 1
+        sc := newTestChecker(&Config{Timeout: time.Second, MaxBody: 1024})
+        sc.store = &ResultStore{}
+        sc.tags = map[string]string{input: "client-a"}
+        sc.variants = map[string]bool{input: true}
+
+        sc.scan(context.Background(), []string{input}, nil, 1)
+        if !reflect.DeepEqual(sc.failedDomains, []string{input}) {
+                t.Fatalf("failedDomains = %q, want the input form %q", sc.failedDomains, input)
+        }
+        sc.retryCodes = sc.failedCodes
+        sc.scan(context.Background(), sc.failedDomains, nil, 1)
+        sc.retryCodes = nil
+
+        stored := sc.store.Snapshot()
+        if len(stored) != 2 {
+                t.Fatalf("stored %d results, want 2", len(stored))
+        }
+        for i, jr := range stored {
+                if jr.Tag != "client-a" || !jr.WWWVariant {
+                        t.Errorf("result %d: tag %q, www variant %v; want client-a, true", i, jr.Tag, jr.WWWVariant)
+                }
+        }
+}